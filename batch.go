@@ -0,0 +1,77 @@
+package gonepassword
+
+import (
+	"context"
+	"strings"
+)
+
+// batchURI is a requested URI together with its parsed form, which may differ after variable expansion.
+type batchURI struct {
+	uri   string
+	opURI *OpURI
+}
+
+// ResolveMany resolves all given 1Password URIs and returns their values keyed by URI.
+// URIs are grouped by vault and item, so every item is fetched through the op CLI at most once.
+// When some URIs fail, the values of the remaining ones are still returned together with
+// a *ResolveManyError listing every failed URI.
+func (cli *OnePassword) ResolveMany(ctx context.Context, uris []string) (map[string]string, error) {
+	return cli.resolveMany(ctx, uris, cli.newOpURI)
+}
+
+// resolveMany works like ResolveMany, parsing the URIs with the given function.
+func (cli *OnePassword) resolveMany(
+	ctx context.Context, uris []string, parse func(uri string) (*OpURI, error),
+) (map[string]string, error) {
+	results := make(map[string]string, len(uris))
+	failures := make(map[string]error)
+	groups := make(map[itemRef][]batchURI)
+	seen := make(map[string]bool, len(uris))
+	var order []itemRef
+
+	for _, uri := range uris {
+		if seen[uri] {
+			continue
+		}
+		seen[uri] = true
+		if !strings.HasPrefix(uri, opURIPrefix) {
+			failures[uri] = &InvalidOpURIError{uri: uri}
+			continue
+		}
+		opURI, err := parse(uri)
+		if err != nil {
+			failures[uri] = err
+			continue
+		}
+		if !cli.isInstalled {
+			failures[uri] = &OnePasswordCliNotInstalledError{}
+			continue
+		}
+		ref := itemRef{vault: opURI.vault, item: opURI.item}
+		if _, ok := groups[ref]; !ok {
+			order = append(order, ref)
+		}
+		groups[ref] = append(groups[ref], batchURI{uri: uri, opURI: opURI})
+	}
+
+	for _, ref := range order {
+		vaultItem, err := cli.fetchVaultItem(ctx, ref.vault, ref.item)
+		for _, batched := range groups[ref] {
+			if err != nil {
+				failures[batched.uri] = err
+				continue
+			}
+			value, fieldErr := vaultItem.GetFieldValue(ctx, cli, batched.opURI)
+			if fieldErr != nil {
+				failures[batched.uri] = fieldErr
+				continue
+			}
+			results[batched.uri] = value
+		}
+	}
+
+	if len(failures) > 0 {
+		return results, &ResolveManyError{Errors: failures}
+	}
+	return results, nil
+}
@@ -0,0 +1,104 @@
+package gonepassword
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// ItemsCommandExecutor answers `op item get` calls with the item registered under the requested name
+// and `op read` calls with the file registered under the requested uri.
+type ItemsCommandExecutor struct {
+	Items map[string]Item
+	Files map[string][]byte
+	Calls map[string]int
+}
+
+func (e *ItemsCommandExecutor) IsInstalled() bool {
+	return true
+}
+
+func (e *ItemsCommandExecutor) Execute(_ context.Context, arg ...string) ([]byte, error) {
+	if arg[0] == "read" {
+		return e.Files[arg[1]], nil
+	}
+	name := arg[4]
+	if e.Calls == nil {
+		e.Calls = make(map[string]int)
+	}
+	e.Calls[name]++
+	item, ok := e.Items[name]
+	if !ok {
+		return nil, &ItemNotFoundError{Vault: arg[6], Item: name}
+	}
+	return json.Marshal(item)
+}
+
+// itemsExecutor returns an ItemsCommandExecutor serving the db and api items most tests resolve their uris against.
+func itemsExecutor() *ItemsCommandExecutor {
+	return &ItemsCommandExecutor{
+		Items: map[string]Item{
+			"db": {ID: "db", Fields: []ItemField{
+				{ID: "host", Value: "localhost"},
+				{ID: "port", Value: "5432"},
+				{ID: "username", Value: "admin"},
+				{ID: "password", Value: "hunter2"},
+				{ID: "pa$word", Value: "s3cret"},
+				{ID: "markup", Value: "<hunter2>"},
+				{ID: "certificate", Value: "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----"},
+				{ID: "otp", Type: "OTP", Value: "otpauth://totp/x?secret=GEZDGNBVGY3TQOJQ"},
+			}, Files: []ItemFile{{ID: "cert", Name: "cert.pem"}}},
+			"api": {ID: "api", Fields: []ItemField{
+				{ID: "token", Value: "t0ken"},
+				{ID: "timeout", Value: "1m30s"},
+				{ID: "ratio", Value: "0.25"},
+				{ID: "enabled", Value: "true"},
+				{ID: "not-a-number", Value: "hunter2"},
+			}},
+		},
+		Files: map[string][]byte{"op://vault/db/cert": []byte("-----BEGIN CERTIFICATE-----")},
+	}
+}
+
+func TestResolveMany(t *testing.T) {
+	executor := &ItemsCommandExecutor{Items: map[string]Item{
+		"db": {ID: "db", Fields: []ItemField{
+			{ID: "username", Label: "username", Value: "admin"},
+			{ID: "password", Label: "password", Value: "s3cret"},
+		}},
+		"api": {ID: "api", Fields: []ItemField{{ID: "token", Label: "token", Value: "t0ken"}}},
+	}}
+	cli, err := New1Password(executor, OnePasswordOptions{})
+	assert.NoError(t, err)
+
+	results, err := cli.ResolveMany(context.Background(), []string{
+		"op://vault/db/username",
+		"op://vault/db/password",
+		"op://vault/api/token",
+		"op://vault/db/username",
+		"op://vault/db/missing",
+		"op://vault/gone/password",
+		"op://vault/gone/username",
+		"not-an-op-uri",
+	})
+
+	assert.Equal(t, map[string]string{
+		"op://vault/db/username": "admin",
+		"op://vault/db/password": "s3cret",
+		"op://vault/api/token":   "t0ken",
+	}, results)
+	assert.Equal(t, map[string]int{"db": 1, "api": 1, "gone": 1}, executor.Calls)
+
+	var resolveManyError *ResolveManyError
+	assert.True(t, errors.As(err, &resolveManyError))
+	assert.Len(t, resolveManyError.Errors, 4)
+	assert.Equal(t, "failed to resolve 4 op uri(s): "+
+		"not-an-op-uri: incorrect op uri - it should look like op://vault/item/field - got not-an-op-uri; "+
+		"op://vault/db/missing: field missing not found; "+
+		"op://vault/gone/password: item gone not found in vault vault; "+
+		"op://vault/gone/username: item gone not found in vault vault", err.Error())
+	var invalidOpURIError *InvalidOpURIError
+	assert.True(t, errors.As(err, &invalidOpURIError))
+}
@@ -0,0 +1,83 @@
+package gonepassword
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	opLogPrefixRegexp       = regexp.MustCompile(`^\[ERROR\] \d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} `)
+	vaultNotFoundRegexp     = regexp.MustCompile(`"([^"]*)" isn't a vault`)
+	itemNotFoundRegexp      = regexp.MustCompile(`"([^"]*)" isn't an item(?: in the "([^"]*)" vault)?`)
+	ambiguousItemRegexp     = regexp.MustCompile(`More than one item matches "([^"]*)"`)
+	ambiguousCandidateRegex = regexp.MustCompile(`(?m)^\s*\*.*:\s*([0-9a-z]+)\s*$`)
+)
+
+var (
+	notSignedInMarkers = []string{
+		"not currently signed in", "not signed in", "no accounts configured", "session expired",
+		"invalid session token",
+	}
+	authorizationDeniedMarkers = []string{
+		"authorization prompt dismissed", "authorization denied", "authorization timeout",
+		"forbidden", "(403)", "permission denied", "do not have permission",
+	}
+	rateLimitedMarkers = []string{"too many requests", "(429)", "rate limit"}
+)
+
+// classifyStderr turns a failure reported by the op CLI on stderr into one of the typed errors,
+// returns nil when the failure is not recognized. args are the op arguments used as a fallback
+// source of vault and item names.
+func classifyStderr(stderr string, args []string) error {
+	message := opLogPrefixRegexp.ReplaceAllString(strings.TrimSpace(stderr), "")
+	lowerMessage := strings.ToLower(message)
+
+	if match := ambiguousItemRegexp.FindStringSubmatch(message); match != nil {
+		var candidates []string
+		for _, candidate := range ambiguousCandidateRegex.FindAllStringSubmatch(message, -1) {
+			candidates = append(candidates, candidate[1])
+		}
+		return &AmbiguousItemError{Vault: argValue(args, "--vault"), Item: match[1], Candidates: candidates}
+	}
+	if match := vaultNotFoundRegexp.FindStringSubmatch(message); match != nil {
+		return &VaultNotFoundError{Vault: match[1]}
+	}
+	if match := itemNotFoundRegexp.FindStringSubmatch(message); match != nil {
+		vault := match[2]
+		if vault == "" {
+			vault = argValue(args, "--vault")
+		}
+		return &ItemNotFoundError{Vault: vault, Item: match[1]}
+	}
+	switch {
+	case containsAny(lowerMessage, notSignedInMarkers):
+		return &NotSignedInError{Message: message}
+	case containsAny(lowerMessage, authorizationDeniedMarkers):
+		return &AuthorizationDeniedError{Message: message}
+	case containsAny(lowerMessage, rateLimitedMarkers):
+		return &RateLimitedError{Message: message}
+	}
+	return nil
+}
+
+// argValue returns the value following the given flag in op arguments.
+func argValue(args []string, flag string) string {
+	for i, arg := range args {
+		if arg == flag && i+1 < len(args) {
+			return args[i+1]
+		}
+		if value, ok := strings.CutPrefix(arg, flag+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func containsAny(s string, markers []string) bool {
+	for _, marker := range markers {
+		if strings.Contains(s, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package gonepassword
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestClassifyStderr(t *testing.T) { //nolint:funlen
+	args := []string{"item", "get", "--format", "json", "db", "--vault", "prod"}
+
+	testCases := []struct {
+		name     string
+		stderr   string
+		expected error
+	}{
+		{
+			name:     "vault not found",
+			stderr:   `[ERROR] 2024/01/01 12:00:00 "prod" isn't a vault in this account. Specify the vault with its ID or name.`,
+			expected: &VaultNotFoundError{Vault: "prod"},
+		},
+		{
+			name: "item not found in vault",
+			stderr: `[ERROR] 2024/01/01 12:00:00 "db" isn't an item in the "prod" vault. ` +
+				`Specify the item with its UUID, name, or domain.`,
+			expected: &ItemNotFoundError{Vault: "prod", Item: "db"},
+		},
+		{
+			name:     "item not found without vault in message",
+			stderr:   `[ERROR] 2024/01/01 12:00:00 "db" isn't an item. Specify the item with its UUID, name, or domain.`,
+			expected: &ItemNotFoundError{Vault: "prod", Item: "db"},
+		},
+		{
+			name: "ambiguous item",
+			stderr: "[ERROR] 2024/01/01 12:00:00 More than one item matches \"db\". " +
+				"Try again and specify the item by its ID:\n" +
+				"\t* for the item \"db\" in vault prod: abcdefghijklmnopqrstuvwxyz\n" +
+				"\t* for the item \"db\" in vault prod: zyxwvutsrqponmlkjihgfedcba\n",
+			expected: &AmbiguousItemError{
+				Vault:      "prod",
+				Item:       "db",
+				Candidates: []string{"abcdefghijklmnopqrstuvwxyz", "zyxwvutsrqponmlkjihgfedcba"},
+			},
+		},
+		{
+			name:     "not signed in",
+			stderr:   "[ERROR] 2024/01/01 12:00:00 You are not currently signed in. Please run `op signin --help`",
+			expected: &NotSignedInError{Message: "You are not currently signed in. Please run `op signin --help`"},
+		},
+		{
+			name:     "authorization prompt dismissed",
+			stderr:   "[ERROR] 2024/01/01 12:00:00 authorization prompt dismissed, please try again",
+			expected: &AuthorizationDeniedError{Message: "authorization prompt dismissed, please try again"},
+		},
+		{
+			name:     "rate limited",
+			stderr:   "[ERROR] 2024/01/01 12:00:00 Too many requests (429)",
+			expected: &RateLimitedError{Message: "Too many requests (429)"},
+		},
+		{
+			name:     "unknown failure",
+			stderr:   "[ERROR] 2024/01/01 12:00:00 something went wrong",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, classifyStderr(tc.stderr, args))
+		})
+	}
+}
+
+func TestClassifiedErrorsSurviveRetry(t *testing.T) {
+	classified := &ItemNotFoundError{Vault: "prod", Item: "db"}
+	var err error = &nonRetryableError{Message: classified.Error(), Err: classified}
+
+	var itemNotFoundError *ItemNotFoundError
+	assert.True(t, errors.As(err, &itemNotFoundError))
+	assert.Equal(t, "item db not found in vault prod", err.Error())
+}
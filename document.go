@@ -0,0 +1,351 @@
+package gonepassword
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// DocumentFormat is an encoding understood by ResolveDocument.
+type DocumentFormat string
+
+// Document formats supported by ResolveDocument.
+const (
+	DocumentJSON DocumentFormat = "json"
+	DocumentYAML DocumentFormat = "yaml"
+	DocumentTOML DocumentFormat = "toml"
+)
+
+// documentSlot is a single op:// string found in a document together with a way to replace it.
+type documentSlot struct {
+	uri string
+	set func(value string)
+}
+
+// ResolveTree replaces, in place, every string starting with op:// found at any depth of a decoded document
+// built from map[string]any, map[any]any, map[string]string, []any and []string values, and returns the tree.
+// A bare string is returned resolved. Every item is fetched only once.
+func (cli *OnePassword) ResolveTree(ctx context.Context, tree any) (any, error) {
+	var slots []documentSlot
+	collectDocumentSlots(tree, func(value string) { tree = value }, &slots)
+	if err := cli.resolveDocumentSlots(ctx, slots); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// ResolveDocument decodes the given JSON, YAML or TOML document, replaces every string value starting with op://
+// with its resolved value and encodes the document again. Key order and comments are kept for YAML, including
+// streams of several documents, key order is kept for JSON, which is re-indented with two spaces. TOML documents
+// are kept as they are apart from the resolved strings, which are written as basic strings.
+func (cli *OnePassword) ResolveDocument(ctx context.Context, data []byte, format DocumentFormat) ([]byte, error) {
+	switch format {
+	case DocumentJSON:
+		return cli.resolveJSONDocument(ctx, data)
+	case DocumentYAML:
+		return cli.resolveYAMLDocument(ctx, data)
+	case DocumentTOML:
+		return cli.resolveTOMLDocument(ctx, data)
+	default:
+		return nil, fmt.Errorf("unsupported document format %s", format)
+	}
+}
+
+func (cli *OnePassword) resolveDocumentSlots(ctx context.Context, slots []documentSlot) error {
+	if len(slots) == 0 {
+		return nil
+	}
+	uris := make([]string, 0, len(slots))
+	for _, slot := range slots {
+		uris = append(uris, slot.uri)
+	}
+	values, err := cli.ResolveMany(ctx, uris)
+	if err != nil {
+		return err
+	}
+	for _, slot := range slots {
+		slot.set(values[slot.uri])
+	}
+	return nil
+}
+
+// collectDocumentSlots walks a decoded document and collects every op:// string in it.
+func collectDocumentSlots(node any, set func(value string), slots *[]documentSlot) {
+	switch typed := node.(type) {
+	case string:
+		if strings.HasPrefix(typed, opURIPrefix) {
+			*slots = append(*slots, documentSlot{uri: typed, set: set})
+		}
+	case map[string]any:
+		for key, value := range typed {
+			collectDocumentSlots(value, func(resolved string) { typed[key] = resolved }, slots)
+		}
+	case map[any]any:
+		for key, value := range typed {
+			collectDocumentSlots(value, func(resolved string) { typed[key] = resolved }, slots)
+		}
+	case map[string]string:
+		for key, value := range typed {
+			collectDocumentSlots(value, func(resolved string) { typed[key] = resolved }, slots)
+		}
+	case []any:
+		for i, value := range typed {
+			collectDocumentSlots(value, func(resolved string) { typed[i] = resolved }, slots)
+		}
+	case []string:
+		for i, value := range typed {
+			collectDocumentSlots(value, func(resolved string) { typed[i] = resolved }, slots)
+		}
+	case jsonObject:
+		for i, member := range typed {
+			collectDocumentSlots(member.value, func(resolved string) { typed[i].value = resolved }, slots)
+		}
+	}
+}
+
+func (cli *OnePassword) resolveJSONDocument(ctx context.Context, data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	tree, err := decodeOrderedJSON(decoder)
+	if err != nil {
+		return nil, fmt.Errorf("invalid json document: %w", err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("invalid json document: unexpected data after top-level value")
+	}
+	if tree, err = cli.ResolveTree(ctx, tree); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(tree); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// resolveYAMLDocument resolves every document of a YAML stream, fetching each item only once across all of them.
+func (cli *OnePassword) resolveYAMLDocument(ctx context.Context, data []byte) ([]byte, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	var documents []*yaml.Node
+	var slots []documentSlot
+	for {
+		var root yaml.Node
+		if err := decoder.Decode(&root); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid yaml document: %w", err)
+		}
+		collectYAMLSlots(&root, &slots)
+		documents = append(documents, &root)
+	}
+	if err := cli.resolveDocumentSlots(ctx, slots); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for _, root := range documents {
+		if err := encoder.Encode(root); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// collectYAMLSlots walks a YAML node tree and collects every op:// string scalar in it.
+// Aliases are skipped, as the anchored node they point to is visited on its own.
+func collectYAMLSlots(node *yaml.Node, slots *[]documentSlot) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!str" && strings.HasPrefix(node.Value, opURIPrefix) {
+			*slots = append(*slots, documentSlot{uri: node.Value, set: func(resolved string) {
+				node.Value = resolved
+				node.Style &^= yaml.LiteralStyle | yaml.FoldedStyle
+				if strings.Contains(resolved, "\n") {
+					node.Style = yaml.LiteralStyle
+				}
+			}})
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			collectYAMLSlots(node.Content[i], slots)
+		}
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			collectYAMLSlots(child, slots)
+		}
+	default:
+	}
+}
+
+// resolveTOMLDocument replaces op:// strings in the TOML source itself, as go-toml cannot encode a document
+// keeping its key order.
+func (cli *OnePassword) resolveTOMLDocument(ctx context.Context, data []byte) ([]byte, error) {
+	var tree map[string]any
+	if err := toml.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("invalid toml document: %w", err)
+	}
+	parser := unstable.Parser{}
+	parser.Reset(data)
+	var ranges []unstable.Range
+	var slots []documentSlot
+	for parser.NextExpression() {
+		collectTOMLSlots(parser.Expression(), &ranges, &slots)
+	}
+	if err := parser.Error(); err != nil {
+		return nil, fmt.Errorf("invalid toml document: %w", err)
+	}
+	resolved := make([]string, len(slots))
+	for i := range slots {
+		slots[i].set = func(value string) { resolved[i] = value }
+	}
+	if err := cli.resolveDocumentSlots(ctx, slots); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	last := 0
+	for i, r := range ranges {
+		buf.Write(data[last:r.Offset])
+		writeTOMLString(&buf, resolved[i])
+		last = int(r.Offset + r.Length)
+	}
+	buf.Write(data[last:])
+	return buf.Bytes(), nil
+}
+
+// collectTOMLSlots walks a TOML expression and collects every op:// string in it with its range in the source.
+// The set function of the collected slots is left for the caller.
+func collectTOMLSlots(node *unstable.Node, ranges *[]unstable.Range, slots *[]documentSlot) {
+	switch node.Kind {
+	case unstable.String:
+		if bytes.HasPrefix(node.Data, []byte(opURIPrefix)) {
+			*ranges = append(*ranges, node.Raw)
+			*slots = append(*slots, documentSlot{uri: string(node.Data)})
+		}
+	case unstable.KeyValue:
+		collectTOMLSlots(node.Value(), ranges, slots)
+	case unstable.Array, unstable.InlineTable:
+		for children := node.Children(); children.Next(); {
+			collectTOMLSlots(children.Node(), ranges, slots)
+		}
+	default:
+	}
+}
+
+// writeTOMLString writes value as a TOML basic string.
+func writeTOMLString(buf *bytes.Buffer, value string) {
+	buf.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case utf8.RuneError:
+			buf.WriteString(`\uFFFD`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(buf, `\u%04X`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// jsonMember is a single key and value of a JSON object.
+type jsonMember struct {
+	key   string
+	value any
+}
+
+// jsonObject is a JSON object which keeps the order of its keys.
+type jsonObject []jsonMember
+
+// MarshalJSON implements json.Marshaler, writing members in their original order.
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	buf.WriteByte('{')
+	for i, member := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encoder.Encode(member.key); err != nil {
+			return nil, err
+		}
+		buf.WriteByte(':')
+		if err := encoder.Encode(member.value); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// decodeOrderedJSON decodes the next JSON value, keeping objects as jsonObject to preserve key order.
+func decodeOrderedJSON(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+	switch delim {
+	case '{':
+		object := jsonObject{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrderedJSON(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, jsonMember{key: key.(string), value: value})
+		}
+		_, err = decoder.Token()
+		return object, err
+	case '[':
+		array := []any{}
+		for decoder.More() {
+			value, err := decodeOrderedJSON(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err = decoder.Token()
+		return array, err
+	default:
+		return nil, fmt.Errorf("unexpected delimiter %s", delim)
+	}
+}
@@ -0,0 +1,187 @@
+package gonepassword
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestResolveTree(t *testing.T) {
+	executor := itemsExecutor()
+	cli, err := New1Password(executor, OnePasswordOptions{})
+	assert.NoError(t, err)
+	tree := map[string]any{
+		"name": "app",
+		"database": map[string]any{
+			"user":     "op://vault/db/username",
+			"password": "op://vault/db/password",
+			"port":     5432,
+		},
+		"replicas": []any{
+			map[any]any{"user": "op://vault/db/username"},
+			[]string{"op://vault/db/password", "plain"},
+		},
+		"env": map[string]string{"DB_PASSWORD": "op://vault/db/password"},
+	}
+
+	resolved, err := cli.ResolveTree(context.Background(), tree)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"name": "app",
+		"database": map[string]any{
+			"user":     "admin",
+			"password": "hunter2",
+			"port":     5432,
+		},
+		"replicas": []any{
+			map[any]any{"user": "admin"},
+			[]string{"hunter2", "plain"},
+		},
+		"env": map[string]string{"DB_PASSWORD": "hunter2"},
+	}, resolved)
+	assert.Equal(t, map[string]int{"db": 1}, executor.Calls)
+
+	resolved, err = cli.ResolveTree(context.Background(), "op://vault/db/username")
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", resolved)
+
+	_, err = cli.ResolveTree(context.Background(), []any{"op://vault/db/missing"})
+	var resolveManyError *ResolveManyError
+	assert.True(t, errors.As(err, &resolveManyError))
+}
+
+func TestResolveDocument(t *testing.T) { //nolint:funlen
+	testCases := []struct {
+		name     string
+		format   DocumentFormat
+		input    string
+		expected string
+	}{
+		{
+			name:   "json keeps key order",
+			format: DocumentJSON,
+			input: `{"zeta": "op://vault/db/username", "alpha": {"password": "op://vault/db/password", ` +
+				`"port": 5432, "ratio": 1.50, "tls": true, "extra": null}, "list": ["op://vault/db/username", "<&>"]}`,
+			expected: `{
+  "zeta": "admin",
+  "alpha": {
+    "password": "hunter2",
+    "port": 5432,
+    "ratio": 1.50,
+    "tls": true,
+    "extra": null
+  },
+  "list": [
+    "admin",
+    "<&>"
+  ]
+}
+`,
+		},
+		{
+			name:   "yaml keeps key order and comments",
+			format: DocumentYAML,
+			input: `# database settings
+zeta: op://vault/db/username
+alpha:
+  password: "op://vault/db/password" # quoted
+  port: 5432
+  certificate: op://vault/db/certificate
+list:
+  - op://vault/db/username
+`,
+			expected: `# database settings
+zeta: admin
+alpha:
+  password: "hunter2" # quoted
+  port: 5432
+  certificate: |-
+    -----BEGIN CERTIFICATE-----
+    MIIB
+    -----END CERTIFICATE-----
+list:
+  - admin
+`,
+		},
+		{
+			name:   "yaml resolves every document of a stream",
+			format: DocumentYAML,
+			input: `a: op://vault/db/password
+---
+b: op://vault/db/username
+`,
+			expected: `a: hunter2
+---
+b: admin
+`,
+		},
+		{
+			name:   "toml keeps the document as it is",
+			format: DocumentTOML,
+			input: `name = 'app' # comment
+
+[database]
+user = 'op://vault/db/username'
+password = "op://vault/db/password"
+port = 5432
+certificate = """op://vault/db/certificate"""
+replica = { user = "op://vault/db/username", hosts = ["op://vault/db/password", "op"] }
+`,
+			expected: `name = 'app' # comment
+
+[database]
+user = "admin"
+password = "hunter2"
+port = 5432
+certificate = "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----"
+replica = { user = "admin", hosts = ["hunter2", "op"] }
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			executor := itemsExecutor()
+			cli, err := New1Password(executor, OnePasswordOptions{})
+			assert.NoError(t, err)
+
+			output, err := cli.ResolveDocument(context.Background(), []byte(tc.input), tc.format)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(output))
+			assert.Equal(t, map[string]int{"db": 1}, executor.Calls)
+		})
+	}
+}
+
+func TestResolveDocumentErrors(t *testing.T) {
+	cli, err := New1Password(itemsExecutor(), OnePasswordOptions{})
+	assert.NoError(t, err)
+
+	_, err = cli.ResolveDocument(context.Background(), []byte(`{}`), "ini")
+	assert.EqualError(t, err, "unsupported document format ini")
+
+	_, err = cli.ResolveDocument(context.Background(), []byte(`{"a": 1} {"b": 2}`), DocumentJSON)
+	assert.EqualError(t, err, "invalid json document: unexpected data after top-level value")
+
+	_, err = cli.ResolveDocument(context.Background(), []byte(`{"a": }`), DocumentJSON)
+	assert.ErrorContains(t, err, "invalid json document")
+
+	_, err = cli.ResolveDocument(context.Background(), []byte(`a: op://vault/db/missing`), DocumentYAML)
+	var resolveManyError *ResolveManyError
+	assert.True(t, errors.As(err, &resolveManyError))
+}
+
+func TestWriteTOMLString(t *testing.T) {
+	value := "quote \" backslash \\ tab \t bell \a delete \x7f unicode ż"
+	var buf bytes.Buffer
+	writeTOMLString(&buf, value)
+
+	var decoded map[string]string
+	assert.NoError(t, toml.Unmarshal([]byte("value = "+buf.String()), &decoded))
+	assert.Equal(t, value, decoded["value"])
+}
@@ -0,0 +1,364 @@
+package gonepassword
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// dotenvPart is a piece of a .env value, either literal text or a reference to a variable.
+type dotenvPart struct {
+	literal  string
+	variable string
+}
+
+// dotenvEntry is a single variable defined in a .env file.
+type dotenvEntry struct {
+	key   string
+	parts []dotenvPart
+}
+
+// expand builds the value of the entry, looking up referenced variables with the given function.
+func (e dotenvEntry) expand(lookup func(name string) string) string {
+	var value strings.Builder
+	for _, part := range e.parts {
+		if part.variable != "" {
+			value.WriteString(lookup(part.variable))
+		} else {
+			value.WriteString(part.literal)
+		}
+	}
+	return value.String()
+}
+
+// isOpURI reports whether the value of the entry is written as an op:// URI. Values only containing
+// an interpolated URI, like `${PASSWORD}@host`, are not URIs themselves.
+func (e dotenvEntry) isOpURI() bool {
+	return len(e.parts) > 0 && strings.HasPrefix(e.parts[0].literal, opURIPrefix)
+}
+
+// ReadDotenv parses a .env file and returns its variables, with every value that is an op:// URI resolved.
+//
+// Lines have the KEY=value form, optionally prefixed with `export`. Values can be unquoted, where a ` #` starts
+// a comment, single-quoted, taken literally, or double-quoted, supporting \n, \r, \t, \", \\ and \$ escapes.
+// Quoted values can span multiple lines. ${VAR} and $VAR in unquoted and double-quoted values are replaced with
+// variables defined earlier in the file or, failing that, with the process environment. Undefined variables expand
+// to an empty string. Only values written as an op:// URI are resolved. Interpolation happens before resolution,
+// so `op://${ENV}/db/password` works, and variables referencing an op:// variable, like `${PASSWORD}@host`, get its
+// resolved value. Resolved URIs are not expanded again, so `$` in a single-quoted URI is taken literally.
+// Every item is fetched only once.
+func (cli *OnePassword) ReadDotenv(ctx context.Context, r io.Reader) (map[string]string, error) {
+	entries, err := parseDotenv(r)
+	if err != nil {
+		return nil, err
+	}
+	values := expandDotenv(entries, func(_ dotenvEntry, value string) string { return value })
+	var uris []string
+	for _, entry := range entries {
+		if entry.isOpURI() {
+			uris = append(uris, values[entry.key])
+		}
+	}
+	if len(uris) == 0 {
+		return values, nil
+	}
+	resolved, err := cli.resolveMany(ctx, uris, parseOpURI)
+	if err != nil {
+		return nil, err
+	}
+	return expandDotenv(entries, func(entry dotenvEntry, value string) string {
+		if entry.isOpURI() {
+			return resolved[value]
+		}
+		return value
+	}), nil
+}
+
+// LoadDotenv reads the given .env files, ".env" when none are given, and sets their variables in the process
+// environment. Variables which are already set are left untouched, so earlier files take precedence.
+func (cli *OnePassword) LoadDotenv(ctx context.Context, paths ...string) error {
+	if len(paths) == 0 {
+		paths = []string{".env"}
+	}
+	for _, path := range paths {
+		if err := cli.loadDotenvFile(ctx, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cli *OnePassword) loadDotenvFile(ctx context.Context, path string) error {
+	file, err := os.Open(path) //nolint:gosec // loading the file the caller asked for is the whole point
+	if err != nil {
+		return err
+	}
+	defer file.Close() //nolint:errcheck
+	values, err := cli.ReadDotenv(ctx, file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for key, value := range values {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandDotenv expands all entries in order, passing every expanded value through resolve.
+func expandDotenv(entries []dotenvEntry, resolve func(entry dotenvEntry, value string) string) map[string]string {
+	values := make(map[string]string, len(entries))
+	lookup := func(name string) string {
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return os.Getenv(name)
+	}
+	for _, entry := range entries {
+		values[entry.key] = resolve(entry, entry.expand(lookup))
+	}
+	return values
+}
+
+// dotenvParser is a cursor over the content of a .env file.
+type dotenvParser struct {
+	data string
+	pos  int
+	line int
+}
+
+func parseDotenv(r io.Reader) ([]dotenvEntry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &dotenvParser{data: strings.ReplaceAll(string(data), "\r\n", "\n"), line: 1}
+	var entries []dotenvEntry
+	for {
+		p.skipBlankLinesAndComments()
+		if p.pos >= len(p.data) {
+			return entries, nil
+		}
+		entry, err := p.parseEntry()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func (p *dotenvParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid .env line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *dotenvParser) skipSpaces() {
+	for p.pos < len(p.data) && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *dotenvParser) skipBlankLinesAndComments() {
+	for {
+		p.skipSpaces()
+		switch {
+		case p.pos >= len(p.data):
+			return
+		case p.data[p.pos] == '\n':
+			p.pos++
+			p.line++
+		case p.data[p.pos] == '#':
+			p.skipToLineEnd()
+		default:
+			return
+		}
+	}
+}
+
+func (p *dotenvParser) skipToLineEnd() {
+	if end := strings.IndexByte(p.data[p.pos:], '\n'); end >= 0 {
+		p.pos += end
+	} else {
+		p.pos = len(p.data)
+	}
+}
+
+func (p *dotenvParser) parseEntry() (dotenvEntry, error) {
+	if rest := p.data[p.pos:]; strings.HasPrefix(rest, "export ") || strings.HasPrefix(rest, "export\t") {
+		p.pos += len("export")
+		p.skipSpaces()
+	}
+	start := p.pos
+	for p.pos < len(p.data) && isDotenvKeyChar(p.data[p.pos], p.pos == start) {
+		p.pos++
+	}
+	key := p.data[start:p.pos]
+	if key == "" {
+		return dotenvEntry{}, p.errorf("expected variable name")
+	}
+	p.skipSpaces()
+	if p.pos >= len(p.data) || p.data[p.pos] != '=' {
+		return dotenvEntry{}, p.errorf("expected = after %s", key)
+	}
+	p.pos++
+	p.skipSpaces()
+	parts, err := p.parseValue()
+	if err != nil {
+		return dotenvEntry{}, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.data) && p.data[p.pos] == '#' {
+		p.skipToLineEnd()
+	}
+	if p.pos < len(p.data) && p.data[p.pos] != '\n' {
+		return dotenvEntry{}, p.errorf("unexpected characters after value of %s", key)
+	}
+	return dotenvEntry{key: key, parts: parts}, nil
+}
+
+func isDotenvKeyChar(c byte, first bool) bool {
+	isLetter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+	if first {
+		return isLetter
+	}
+	return isLetter || (c >= '0' && c <= '9') || c == '.' || c == '-'
+}
+
+func (p *dotenvParser) parseValue() ([]dotenvPart, error) {
+	if p.pos >= len(p.data) {
+		return nil, nil
+	}
+	switch p.data[p.pos] {
+	case '\'':
+		end := strings.IndexByte(p.data[p.pos+1:], '\'')
+		if end < 0 {
+			return nil, p.errorf("unterminated single-quoted value")
+		}
+		value := p.data[p.pos+1 : p.pos+1+end]
+		p.line += strings.Count(value, "\n")
+		p.pos += end + 2
+		return []dotenvPart{{literal: value}}, nil
+	case '"':
+		return p.parseDoubleQuotedValue()
+	default:
+		start := p.pos
+		p.skipToLineEnd()
+		value := p.data[start:p.pos]
+		if comment := strings.Index(value, " #"); comment >= 0 {
+			value = value[:comment]
+		}
+		if comment := strings.Index(value, "\t#"); comment >= 0 {
+			value = value[:comment]
+		}
+		return p.interpolate(strings.TrimSpace(value))
+	}
+}
+
+var dotenvEscapes = map[byte]byte{'n': '\n', 'r': '\r', 't': '\t', '"': '"', '\\': '\\', '$': '$'}
+
+func (p *dotenvParser) parseDoubleQuotedValue() ([]dotenvPart, error) {
+	var parts []dotenvPart
+	var literal strings.Builder
+	p.pos++
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		switch {
+		case c == '"':
+			p.pos++
+			return append(parts, dotenvPart{literal: literal.String()}), nil
+		case c == '\\' && p.pos+1 < len(p.data):
+			if escaped, ok := dotenvEscapes[p.data[p.pos+1]]; ok {
+				literal.WriteByte(escaped)
+				p.pos += 2
+				continue
+			}
+			literal.WriteByte(c)
+			p.pos++
+		case c == '$':
+			name, width, err := p.readVariable(p.data, p.pos)
+			if err != nil {
+				return nil, err
+			}
+			if name == "" {
+				literal.WriteByte(c)
+				p.pos++
+				continue
+			}
+			parts = append(parts, dotenvPart{literal: literal.String()}, dotenvPart{variable: name})
+			literal.Reset()
+			p.pos += width
+		default:
+			if c == '\n' {
+				p.line++
+			}
+			literal.WriteByte(c)
+			p.pos++
+		}
+	}
+	return nil, p.errorf("unterminated double-quoted value")
+}
+
+// interpolate splits an unquoted value into literals and variable references.
+func (p *dotenvParser) interpolate(value string) ([]dotenvPart, error) {
+	var parts []dotenvPart
+	last := 0
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' {
+			continue
+		}
+		name, width, err := p.readVariable(value, i)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			continue
+		}
+		parts = append(parts, dotenvPart{literal: value[last:i]}, dotenvPart{variable: name})
+		i += width - 1
+		last = i + 1
+	}
+	return append(parts, dotenvPart{literal: value[last:]}), nil
+}
+
+// readVariable reads a ${NAME} or $NAME reference starting at s[i], returning its name and length.
+// An empty name means the $ does not start a reference.
+func (p *dotenvParser) readVariable(s string, i int) (string, int, error) {
+	if i+1 < len(s) && s[i+1] == '{' {
+		end := strings.IndexByte(s[i+2:], '}')
+		if end < 0 {
+			return "", 0, p.errorf("unterminated variable reference")
+		}
+		name := s[i+2 : i+2+end]
+		if !isVariableName(name) {
+			return "", 0, p.errorf("invalid variable reference ${%s}", name)
+		}
+		return name, end + 3, nil
+	}
+	end := i + 1
+	for end < len(s) && isVariableChar(s[end], end == i+1) {
+		end++
+	}
+	return s[i+1 : end], end - i, nil
+}
+
+func isVariableName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isVariableChar(name[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func isVariableChar(c byte, first bool) bool {
+	isLetter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+	return isLetter || (!first && c >= '0' && c <= '9')
+}
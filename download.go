@@ -0,0 +1,125 @@
+package gonepassword
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileDownload describes a file or document written by DownloadFile or GetDocument.
+type FileDownload struct {
+	// Size is the number of bytes written.
+	Size int64
+	// SHA256 is the hex encoded SHA-256 checksum of the written bytes.
+	SHA256 string
+}
+
+// outFileName is the name of the file the op CLI writes downloads to, inside a private temporary directory.
+const outFileName = "download"
+
+// DownloadFile writes the file attachment referenced by uri to w, byte for byte.
+// The op CLI writes the file to a private temporary directory first, so the file is never held in memory as a whole.
+// Files larger than OnePasswordOptions.MaxFileSize are refused with a FileTooLargeError before anything is written.
+func (cli *OnePassword) DownloadFile(ctx context.Context, uri string, w io.Writer) (FileDownload, error) {
+	if !strings.HasPrefix(uri, opURIPrefix) {
+		return FileDownload{}, &InvalidOpURIError{uri: uri}
+	}
+	opURI, err := cli.newOpURI(uri)
+	if err != nil {
+		return FileDownload{}, err
+	}
+	if !cli.isInstalled {
+		return FileDownload{}, &OnePasswordCliNotInstalledError{}
+	}
+	vaultItem, err := cli.fetchVaultItem(ctx, opURI.vault, opURI.item)
+	if err != nil {
+		return FileDownload{}, err
+	}
+	for _, f := range vaultItem.Files {
+		if !f.matchFile(opURI) {
+			continue
+		}
+		if err := cli.checkFileSize(f.Name, f.Size); err != nil {
+			return FileDownload{}, err
+		}
+		return cli.download(ctx, f.Name, w, func(path string) []string {
+			return []string{"read", "--out-file", path, vaultItem.fileReference(opURI, f)}
+		})
+	}
+	return FileDownload{}, &FieldNotFoundError{Section: opURI.section, Field: opURI.field}
+}
+
+// GetDocument writes the content of the given DOCUMENT item to w, vault and item may be given by name or ID.
+// Documents larger than OnePasswordOptions.MaxFileSize are refused with a FileTooLargeError, the item is fetched
+// to check the size before anything is downloaded when the limit is set.
+func (cli *OnePassword) GetDocument(ctx context.Context, vault string, item string, w io.Writer) (FileDownload, error) {
+	if !cli.isInstalled {
+		return FileDownload{}, &OnePasswordCliNotInstalledError{}
+	}
+	if cli.options.MaxFileSize > 0 {
+		document, err := cli.fetchVaultItem(ctx, vault, item)
+		if err != nil {
+			return FileDownload{}, err
+		}
+		for _, f := range document.Files {
+			if err := cli.checkFileSize(f.Name, f.Size); err != nil {
+				return FileDownload{}, err
+			}
+		}
+	}
+	return cli.download(ctx, item, w, func(path string) []string {
+		return []string{"document", "get", item, "--vault", vault, "--out-file", path}
+	})
+}
+
+// download runs the op command returned by command, which writes a file to the given path,
+// and copies that file to w while computing its checksum.
+func (cli *OnePassword) download(
+	ctx context.Context, name string, w io.Writer, command func(path string) []string,
+) (FileDownload, error) {
+	var download FileDownload
+	err := withTempDir(func(dir string) error {
+		path := filepath.Join(dir, outFileName)
+		if _, err := cli.execute(ctx, command(path)...); err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+		info, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		if err := cli.checkFileSize(name, info.Size()); err != nil {
+			return err
+		}
+		hash := sha256.New()
+		size, err := io.Copy(io.MultiWriter(w, hash), file)
+		if err != nil {
+			return err
+		}
+		download = FileDownload{Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}
+		return nil
+	})
+	if err != nil {
+		return FileDownload{}, err
+	}
+	cli.logger.DebugContext(ctx, "downloaded 1Password file", "name", name, "size", download.Size)
+	return download, nil
+}
+
+// checkFileSize returns a FileTooLargeError when size exceeds the configured MaxFileSize.
+func (cli *OnePassword) checkFileSize(name string, size int64) error {
+	if cli.options.MaxFileSize > 0 && size > cli.options.MaxFileSize {
+		return &FileTooLargeError{Name: name, Size: size, Limit: cli.options.MaxFileSize}
+	}
+	return nil
+}
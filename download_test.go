@@ -0,0 +1,117 @@
+package gonepassword
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// OutFileCommandExecutor answers `op item get` with Item and writes Content to the `--out-file` of other calls.
+type OutFileCommandExecutor struct {
+	Item    Item
+	Content []byte
+	Calls   [][]string
+}
+
+func (e *OutFileCommandExecutor) IsInstalled() bool {
+	return true
+}
+
+func (e *OutFileCommandExecutor) Execute(_ context.Context, arg ...string) ([]byte, error) {
+	e.Calls = append(e.Calls, arg)
+	if path := argValue(arg, "--out-file"); path != "" {
+		return nil, os.WriteFile(path, e.Content, 0o600)
+	}
+	return json.Marshal(e.Item)
+}
+
+func TestDownloadFile(t *testing.T) {
+	content := []byte{0x00, 0xff, 0xfe, '\n', 0x80, 'k', 'e', 'y'}
+	checksum := sha256.Sum256(content)
+	executor := &OutFileCommandExecutor{
+		Item: Item{ID: "abc123", Vault: ItemVault{ID: "v1"},
+			Files: []ItemFile{{ID: "f1", Name: "keystore.p12", Size: int64(len(content))}}},
+		Content: content,
+	}
+	cli, err := New1Password(executor, OnePasswordOptions{})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	download, err := cli.DownloadFile(context.Background(), "op://vault/tls/keystore.p12", &buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, content, buf.Bytes())
+	assert.Equal(t, FileDownload{Size: int64(len(content)), SHA256: hex.EncodeToString(checksum[:])}, download)
+	readArgs := executor.Calls[1]
+	assert.Equal(t, []string{"read", "--out-file", readArgs[2], "op://v1/abc123/f1"}, readArgs)
+	_, err = os.Stat(filepath.Dir(readArgs[2]))
+	assert.True(t, os.IsNotExist(err), "temporary directory should be removed")
+}
+
+func TestDownloadFileHonoursSizeLimit(t *testing.T) {
+	executor := &OutFileCommandExecutor{
+		Item:    Item{ID: "abc123", Files: []ItemFile{{ID: "f1", Name: "bundle.pem", Size: 2048}}},
+		Content: make([]byte, 2048),
+	}
+	cli, err := New1Password(executor, OnePasswordOptions{MaxFileSize: 1024})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	_, err = cli.DownloadFile(context.Background(), "op://vault/tls/bundle.pem", &buf)
+
+	var fileTooLargeError *FileTooLargeError
+	assert.True(t, errors.As(err, &fileTooLargeError))
+	assert.EqualError(t, err, "file bundle.pem is 2048 bytes, which exceeds the limit of 1024 bytes")
+	assert.Len(t, executor.Calls, 1, "file should not be downloaded")
+	assert.Zero(t, buf.Len())
+
+	_, err = cli.DownloadFile(context.Background(), "op://vault/tls/missing.pem", &buf)
+	assert.EqualError(t, err, "field missing.pem not found")
+}
+
+func TestGetDocument(t *testing.T) {
+	executor := &OutFileCommandExecutor{Content: []byte("-----BEGIN CERTIFICATE-----")}
+	cli, err := New1Password(executor, OnePasswordOptions{Account: "my.1password.com"})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	download, err := cli.GetDocument(context.Background(), "vault", "ca.pem", &buf)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "-----BEGIN CERTIFICATE-----", buf.String())
+	assert.Equal(t, int64(27), download.Size)
+	path := argValue(executor.Calls[0], "--out-file")
+	assert.Equal(t, []string{"document", "get", "ca.pem", "--vault", "vault", "--out-file", path,
+		"--account", "my.1password.com"}, executor.Calls[0])
+
+}
+
+func TestGetDocumentHonoursSizeLimit(t *testing.T) {
+	executor := &OutFileCommandExecutor{
+		Item:    Item{ID: "abc123", Category: "DOCUMENT", Files: []ItemFile{{ID: "f1", Name: "ca.pem", Size: 27}}},
+		Content: []byte("-----BEGIN CERTIFICATE-----"),
+	}
+	cli, err := New1Password(executor, OnePasswordOptions{MaxFileSize: 10})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	_, err = cli.GetDocument(context.Background(), "vault", "ca.pem", &buf)
+
+	var fileTooLargeError *FileTooLargeError
+	assert.True(t, errors.As(err, &fileTooLargeError))
+	assert.Equal(t, [][]string{{"item", "get", "--format", "json", "ca.pem", "--vault", "vault"}}, executor.Calls,
+		"document should not be downloaded")
+	assert.Zero(t, buf.Len())
+
+	cli.options.MaxFileSize = 27
+	_, err = cli.GetDocument(context.Background(), "vault", "ca.pem", &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "-----BEGIN CERTIFICATE-----", buf.String())
+}
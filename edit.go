@@ -0,0 +1,104 @@
+package gonepassword
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+)
+
+// templateFileName is the name of the temporary file item templates are passed to the op CLI in.
+const templateFileName = "template.json"
+
+// ItemTemplate describes an item to create, or the new content of an edited item,
+// in the format of `op item template get`.
+type ItemTemplate struct {
+	Title    string            `json:"title"`
+	Category string            `json:"category"`
+	Tags     []string          `json:"tags,omitempty"`
+	URLs     []ItemURL         `json:"urls,omitempty"`
+	Sections []TemplateSection `json:"sections,omitempty"`
+	Fields   []TemplateField   `json:"fields,omitempty"`
+	// GeneratePassword generates the password of a new item, it is ignored by EditItem.
+	GeneratePassword *PasswordRecipe `json:"-"`
+}
+
+// TemplateSection is a section of an ItemTemplate, fields refer to it by ID.
+type TemplateSection struct {
+	ID    string `json:"id"`
+	Label string `json:"label,omitempty"`
+}
+
+// TemplateField is a field of an ItemTemplate. Type is e.g. STRING or CONCEALED, Purpose is USERNAME,
+// PASSWORD or NOTES for the built-in fields of a category.
+type TemplateField struct {
+	ID      string           `json:"id,omitempty"`
+	Type    string           `json:"type"`
+	Purpose string           `json:"purpose,omitempty"`
+	Label   string           `json:"label"`
+	Value   string           `json:"value"`
+	Section *TemplateSection `json:"section,omitempty"`
+}
+
+// CreateItem creates a new item in the given vault and returns it. The created item is cached,
+// so resolving its fields right away does not call the op CLI again.
+// When template.GeneratePassword is set, the PASSWORD field of the item is generated from that recipe.
+func (cli *OnePassword) CreateItem(ctx context.Context, vault string, template ItemTemplate) (Item, error) {
+	template, generateArgs, err := generatePasswordArgs(template)
+	if err != nil {
+		return Item{}, err
+	}
+	args := append([]string{"item", "create", "--vault", vault}, generateArgs...)
+	created, err := cli.writeItem(ctx, template, args...)
+	if err != nil {
+		return Item{}, err
+	}
+	cli.setVaultItem(vault, created.ID, created)
+	return created.clone(), nil
+}
+
+// EditItem replaces the content of the given item with template and returns the edited item.
+// The cached copy of the item is replaced, vault and item may be given by name or ID.
+func (cli *OnePassword) EditItem(ctx context.Context, vault string, item string, template ItemTemplate) (Item, error) {
+	edited, err := cli.writeItem(ctx, template, "item", "edit", item, "--vault", vault)
+	if err != nil {
+		return Item{}, err
+	}
+	cli.invalidateItem(vault, item)
+	cli.setVaultItem(vault, item, edited)
+	return edited.clone(), nil
+}
+
+// DeleteItem permanently deletes the given item and drops it from the cache.
+func (cli *OnePassword) DeleteItem(ctx context.Context, vault string, item string) error {
+	if _, err := cli.execute(ctx, "item", "delete", item, "--vault", vault); err != nil {
+		return err
+	}
+	cli.invalidateItem(vault, item)
+	return nil
+}
+
+// ArchiveItem moves the given item to the archive and drops it from the cache.
+func (cli *OnePassword) ArchiveItem(ctx context.Context, vault string, item string) error {
+	if _, err := cli.execute(ctx, "item", "delete", item, "--vault", vault, "--archive"); err != nil {
+		return err
+	}
+	cli.invalidateItem(vault, item)
+	return nil
+}
+
+// writeItem runs the given op command with template passed through a temporary `--template` file
+// and returns the item printed by the op CLI.
+func (cli *OnePassword) writeItem(ctx context.Context, template ItemTemplate, arg ...string) (Item, error) {
+	data, err := json.Marshal(template)
+	if err != nil {
+		return Item{}, err
+	}
+	var written Item
+	err = withTempFile(templateFileName, bytes.NewReader(data), func(path string) error {
+		return cli.executeJSON(ctx, &written, append(arg, "--template", path)...)
+	})
+	if err != nil {
+		return Item{}, err
+	}
+	return written, nil
+}
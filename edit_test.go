@@ -0,0 +1,157 @@
+package gonepassword
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TempFileCommandExecutor records op calls together with the path, content and mode of the temporary file
+// passed to op - a `--template`, a `[file]=` assignment or the document of `document create` - as they were
+// while op was running, and answers every call with Output.
+type TempFileCommandExecutor struct {
+	Output   []byte
+	Error    error
+	Calls    [][]string
+	Path     string
+	Content  []byte
+	FileMode os.FileMode
+}
+
+func (e *TempFileCommandExecutor) IsInstalled() bool {
+	return true
+}
+
+func (e *TempFileCommandExecutor) Execute(_ context.Context, arg ...string) ([]byte, error) {
+	e.Calls = append(e.Calls, arg)
+	path := argValue(arg, "--template")
+	for i, a := range arg {
+		if _, file, ok := strings.Cut(a, "[file]="); ok {
+			path = file
+		}
+		if a == "create" && arg[0] == "document" && i+1 < len(arg) {
+			path = arg[i+1]
+		}
+	}
+	if path != "" {
+		e.Path = path
+		e.Content, _ = os.ReadFile(path)
+		if info, err := os.Stat(path); err == nil {
+			e.FileMode = info.Mode().Perm()
+		}
+	}
+	return e.Output, e.Error
+}
+
+func TestCreateItem(t *testing.T) {
+	created, err := json.Marshal(Item{ID: "abc123", Title: "db", Category: "DATABASE",
+		Vault:  ItemVault{ID: "v1", Name: "Private"},
+		Fields: []ItemField{{ID: "password", Label: "password", Value: "s3cret"}}})
+	assert.NoError(t, err)
+	executor := &TempFileCommandExecutor{Output: created}
+	cli, err := New1Password(executor, OnePasswordOptions{})
+	assert.NoError(t, err)
+
+	item, err := cli.CreateItem(context.Background(), "Private", ItemTemplate{
+		Title:    "db",
+		Category: "DATABASE",
+		Fields:   []TemplateField{{ID: "password", Type: "CONCEALED", Label: "password", Value: "s3cret"}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, Item{ID: "abc123", Title: "db", Category: "DATABASE", Vault: ItemVault{ID: "v1", Name: "Private"},
+		Fields: []ItemField{{ID: "password", Label: "password", Value: "s3cret"}}}, item)
+	assert.Equal(t, [][]string{{"item", "create", "--vault", "Private", "--template", executor.Path,
+		"--format", "json"}}, executor.Calls)
+	assert.JSONEq(t, `{"title": "db", "category": "DATABASE", "fields": [
+		{"id": "password", "type": "CONCEALED", "label": "password", "value": "s3cret"}
+	]}`, string(executor.Content))
+	assert.Equal(t, os.FileMode(0o600), executor.FileMode)
+	_, err = os.Stat(executor.Path)
+	assert.True(t, os.IsNotExist(err), "template file should be removed")
+
+	value, err := cli.ResolveOpURI("op://Private/db/password")
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+	assert.Len(t, executor.Calls, 1)
+}
+
+func TestEditItemReplacesCachedItem(t *testing.T) {
+	cli, err := New1Password(&TempFileCommandExecutor{}, OnePasswordOptions{})
+	assert.NoError(t, err)
+	cli.setVaultItem("vault", "db", Item{ID: "abc123", Title: "db",
+		Fields: []ItemField{{ID: "password", Value: "old"}}})
+	edited, err := json.Marshal(Item{ID: "abc123", Title: "db", Version: 2,
+		Fields: []ItemField{{ID: "password", Value: "new"}}})
+	assert.NoError(t, err)
+	executor := &TempFileCommandExecutor{Output: edited}
+	cli.executor = executor
+
+	item, err := cli.EditItem(context.Background(), "vault", "db", ItemTemplate{Title: "db", Category: "DATABASE"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, item.Version)
+	assert.Equal(t, "edit", executor.Calls[0][1])
+	assert.Equal(t, "db", executor.Calls[0][2])
+	for _, uri := range []string{"op://vault/db/password", "op://vault/abc123/password"} {
+		value, err := cli.ResolveOpURI(uri)
+		assert.NoError(t, err)
+		assert.Equal(t, "new", value)
+	}
+	assert.Len(t, executor.Calls, 1)
+}
+
+func TestDeleteAndArchiveItemEvictCachedItem(t *testing.T) {
+	testCases := []struct {
+		name         string
+		remove       func(cli *OnePassword) error
+		expectedArgs []string
+	}{
+		{
+			name:         "delete",
+			remove:       func(cli *OnePassword) error { return cli.DeleteItem(context.Background(), "vault", "db") },
+			expectedArgs: []string{"item", "delete", "db", "--vault", "vault"},
+		},
+		{
+			name:         "archive",
+			remove:       func(cli *OnePassword) error { return cli.ArchiveItem(context.Background(), "vault", "db") },
+			expectedArgs: []string{"item", "delete", "db", "--vault", "vault", "--archive"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			executor := &TempFileCommandExecutor{}
+			cli, err := New1Password(executor, OnePasswordOptions{})
+			assert.NoError(t, err)
+			cli.setVaultItem("vault", "db", Item{ID: "abc123", Title: "db"})
+
+			assert.NoError(t, tc.remove(cli))
+
+			assert.Equal(t, [][]string{tc.expectedArgs}, executor.Calls)
+			_, err = cli.getVaultItem("vault", "abc123")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestWriteFailureKeepsCachedItem(t *testing.T) {
+	executor := &TempFileCommandExecutor{Error: &ItemNotFoundError{Vault: "vault", Item: "db"}}
+	cli, err := New1Password(executor, OnePasswordOptions{})
+	assert.NoError(t, err)
+	cli.setVaultItem("vault", "db", Item{ID: "abc123", Title: "db"})
+
+	_, err = cli.EditItem(context.Background(), "vault", "db", ItemTemplate{Title: "db"})
+	var itemNotFoundError *ItemNotFoundError
+	assert.True(t, errors.As(err, &itemNotFoundError))
+	assert.Error(t, cli.DeleteItem(context.Background(), "vault", "db"))
+
+	_, err = cli.getVaultItem("vault", "db")
+	assert.NoError(t, err)
+	_, err = os.Stat(executor.Path)
+	assert.True(t, os.IsNotExist(err), "template file should be removed")
+}
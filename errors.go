@@ -1,6 +1,10 @@
 package gonepassword
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
 
 // InvalidOpURIError is returned when the op uri is not in the correct format.
 type InvalidOpURIError struct {
@@ -19,3 +23,125 @@ func (e OnePasswordCliNotInstalledError) Error() string {
 	return "1Password CLI is not installed, visit https://support.1password.com/command-line/ " +
 		"for installation instructions"
 }
+
+// ResolveManyError is returned by ResolveMany when at least one of the requested URIs could not be resolved.
+// Errors maps every failed URI to the reason it failed.
+type ResolveManyError struct {
+	Errors map[string]error
+}
+
+func (e ResolveManyError) Error() string {
+	uris := make([]string, 0, len(e.Errors))
+	for uri := range e.Errors {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	failures := make([]string, 0, len(uris))
+	for _, uri := range uris {
+		failures = append(failures, fmt.Sprintf("%s: %s", uri, e.Errors[uri]))
+	}
+	return fmt.Sprintf("failed to resolve %d op uri(s): %s", len(failures), strings.Join(failures, "; "))
+}
+
+// Unwrap returns all underlying errors, so errors.Is and errors.As can inspect individual failures.
+func (e ResolveManyError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// VaultNotFoundError is returned when the vault does not exist or is not accessible to the signed in account.
+type VaultNotFoundError struct {
+	Vault string
+}
+
+func (e VaultNotFoundError) Error() string {
+	return fmt.Sprintf("vault %s not found", e.Vault)
+}
+
+// ItemNotFoundError is returned when the item does not exist in the vault.
+type ItemNotFoundError struct {
+	Vault string
+	Item  string
+}
+
+func (e ItemNotFoundError) Error() string {
+	if e.Vault == "" {
+		return fmt.Sprintf("item %s not found", e.Item)
+	}
+	return fmt.Sprintf("item %s not found in vault %s", e.Item, e.Vault)
+}
+
+// FieldNotFoundError is returned when the item has no field or file matching the op uri.
+type FieldNotFoundError struct {
+	Section string
+	Field   string
+}
+
+func (e FieldNotFoundError) Error() string {
+	return fmt.Sprintf("field %s not found", e.Field)
+}
+
+// AmbiguousItemError is returned when more than one item matches the item name,
+// Candidates holds the IDs of all matching items.
+type AmbiguousItemError struct {
+	Vault      string
+	Item       string
+	Candidates []string
+}
+
+func (e AmbiguousItemError) Error() string {
+	return fmt.Sprintf("more than one item matches %s - use one of the item IDs instead: %s",
+		e.Item, strings.Join(e.Candidates, ", "))
+}
+
+// NotSignedInError is returned when the op CLI has no signed in account or service account token to use.
+type NotSignedInError struct {
+	Message string
+}
+
+func (e NotSignedInError) Error() string {
+	return "not signed in to 1Password: " + e.Message
+}
+
+// AuthorizationDeniedError is returned when access was denied, e.g. the 1Password app prompt was dismissed
+// or the account has no permission to perform the operation.
+type AuthorizationDeniedError struct {
+	Message string
+}
+
+func (e AuthorizationDeniedError) Error() string {
+	return "1Password authorization denied: " + e.Message
+}
+
+// RateLimitedError is returned when 1Password rejected the request due to rate limiting.
+type RateLimitedError struct {
+	Message string
+}
+
+func (e RateLimitedError) Error() string {
+	return "1Password rate limit exceeded: " + e.Message
+}
+
+// UndefinedVariableError is returned when an op uri references a variable which is not defined.
+type UndefinedVariableError struct {
+	URI  string
+	Name string
+}
+
+func (e UndefinedVariableError) Error() string {
+	return fmt.Sprintf("op uri %s references undefined variable %s", e.URI, e.Name)
+}
+
+// FileTooLargeError is returned when a file or document is larger than the configured MaxFileSize.
+type FileTooLargeError struct {
+	Name  string
+	Size  int64
+	Limit int64
+}
+
+func (e FileTooLargeError) Error() string {
+	return fmt.Sprintf("file %s is %d bytes, which exceeds the limit of %d bytes", e.Name, e.Size, e.Limit)
+}
@@ -2,30 +2,42 @@ package gonepassword
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
-	"github.com/sirupsen/logrus"
+	"io"
+	"log/slog"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 )
 
 // CommandExecutor is an interface for executing commands through op CLI.
 type CommandExecutor interface {
 	IsInstalled() bool
-	Execute(arg ...string) ([]byte, error)
+	Execute(ctx context.Context, arg ...string) ([]byte, error)
 }
 
 // DefaultCommandExecutor is the default implementation of CommandExecutor.
 type DefaultCommandExecutor struct {
 	serviceAccountToken string
+	retryPolicy         RetryPolicy
+	logger              *slog.Logger
+	stderr              io.Writer
 }
 
 // Execute executes the given command and returns its output.
-func (e DefaultCommandExecutor) Execute(arg ...string) ([]byte, error) {
-	output, err := retry(retryAttempts, exponentialBackoff, func() (any, error) {
+// Failed calls are retried according to the executor retry policy.
+// Cancelling ctx kills the running op process and stops any pending retries.
+func (e DefaultCommandExecutor) Execute(ctx context.Context, arg ...string) ([]byte, error) {
+	logger := loggerOrDefault(e.logger)
+	attempt := 0
+	output, err := retry(ctx, e.retryPolicy, logger, func(ctx context.Context) (any, error) {
+		attempt++
+		start := time.Now()
 		var stdErr bytes.Buffer
-		executor := exec.Command(binName, arg...) //nolint:gosec // wrapper intentionally shells out to the op CLI
+		executor := exec.CommandContext(ctx, binName, arg...) //nolint:gosec // wrapper intentionally shells out to the op CLI
 		if e.serviceAccountToken != "" {
 			executor.Env = append(
 				os.Environ(), fmt.Sprintf("%s=%s", serviceAccountTokenEnv, e.serviceAccountToken),
@@ -33,17 +45,39 @@ func (e DefaultCommandExecutor) Execute(arg ...string) ([]byte, error) {
 		}
 		executor.Stderr = &stdErr
 		output, err := executor.Output()
-		_, _ = os.Stderr.Write(stdErr.Bytes())
+		if e.stderr != nil {
+			_, _ = e.stderr.Write(stdErr.Bytes())
+		}
+		logger.DebugContext(ctx, "op command finished", "command", commandName(arg), "attempt", attempt,
+			"duration", time.Since(start), "success", err == nil)
 		if err != nil {
-			if strings.Contains(stdErr.String(), "https://") {
-				logrus.Error("it looks like 1password-1problem, let's ask them again...\n")
-				return output, errors.New(stdErr.String())
+			if ctx.Err() != nil {
+				return output, ctx.Err()
+			}
+			if classified := classifyStderr(stdErr.String(), arg); classified != nil {
+				return output, classified
+			}
+			if stdErr.Len() == 0 {
+				return output, err
 			}
-			return output, &nonRetryableError{stdErr.String()}
+			return output, errors.New(stdErr.String())
 		}
 		return output, err
 	})
-	return output.([]byte), err
+	bytesOutput, _ := output.([]byte)
+	return bytesOutput, err
+}
+
+// commandName returns the op subcommand without its arguments, which may hold names worth keeping out of logs.
+func commandName(arg []string) string {
+	name := make([]string, 0, 2)
+	for _, a := range arg {
+		if len(name) == 2 || strings.HasPrefix(a, "-") {
+			break
+		}
+		name = append(name, a)
+	}
+	return strings.Join(name, " ")
 }
 
 // IsInstalled returns true if the 1Password CLI is installed.
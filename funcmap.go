@@ -0,0 +1,67 @@
+package gonepassword
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"text/template"
+)
+
+// FuncMap returns template functions resolving secrets through the client and its item cache:
+//
+//	{{ op "op://vault/item/field" }}                  the resolved value
+//	{{ opTOTP "op://vault/item/one-time password" }}  the current one-time password code
+//	{{ opFile "op://vault/item/cert.pem" }}           the content of an attached file
+//	{{ opDefault "op://vault/item/field" "fallback" }} the resolved value, or fallback when the vault,
+//	                                                  item or field does not exist
+//
+// The map works with text/template directly, convert it with html/template.FuncMap(m) for html/template.
+func (cli *OnePassword) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"op": func(uri string) (string, error) {
+			return cli.ResolveOpURI(uri)
+		},
+		"opTOTP": func(uri string) (string, error) {
+			totp, err := cli.ResolveTOTP(uri)
+			return totp.Code, err
+		},
+		"opFile": func(uri string) (string, error) {
+			return cli.resolveFile(context.Background(), uri)
+		},
+		"opDefault": func(uri string, fallback string) (string, error) {
+			value, err := cli.ResolveOpURI(uri)
+			if isNotFoundError(err) {
+				return fallback, nil
+			}
+			return value, err
+		},
+	}
+}
+
+// resolveFile resolves the given 1Password URI pointing to a file attached to an item and returns its content.
+func (cli *OnePassword) resolveFile(ctx context.Context, uri string) (string, error) {
+	if !strings.HasPrefix(uri, opURIPrefix) {
+		return "", &InvalidOpURIError{uri: uri}
+	}
+	opURI, err := cli.newOpURI(uri)
+	if err != nil {
+		return "", err
+	}
+	if !cli.isInstalled {
+		return "", &OnePasswordCliNotInstalledError{}
+	}
+	vaultItem, err := cli.fetchVaultItem(ctx, opURI.vault, opURI.item)
+	if err != nil {
+		return "", err
+	}
+	return vaultItem.GetFileValue(ctx, cli, opURI)
+}
+
+// isNotFoundError reports whether err means the vault, item or field does not exist.
+func isNotFoundError(err error) bool {
+	var vaultNotFoundError *VaultNotFoundError
+	var itemNotFoundError *ItemNotFoundError
+	var fieldNotFoundError *FieldNotFoundError
+	return errors.As(err, &vaultNotFoundError) || errors.As(err, &itemNotFoundError) ||
+		errors.As(err, &fieldNotFoundError)
+}
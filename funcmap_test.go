@@ -0,0 +1,63 @@
+package gonepassword
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	htmltemplate "html/template"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func TestFuncMap(t *testing.T) {
+	executor := itemsExecutor()
+	cli, err := New1Password(executor, OnePasswordOptions{})
+	assert.NoError(t, err)
+	cli.now = func() time.Time { return time.Unix(1704067200, 0) }
+	tmpl := template.Must(template.New("config").Funcs(cli.FuncMap()).Parse(
+		`password={{ op "op://vault/db/markup" }}
+otp={{ opTOTP "op://vault/db/otp" }}
+cert={{ opFile "op://vault/db/cert.pem" }}
+user={{ opDefault "op://vault/db/username" "admin" }}
+missing={{ opDefault "op://vault/missing/username" "guest" }}
+`))
+	var rendered bytes.Buffer
+
+	err = tmpl.Execute(&rendered, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `password=<hunter2>
+otp=701317
+cert=-----BEGIN CERTIFICATE-----
+user=admin
+missing=guest
+`, rendered.String())
+	assert.Equal(t, map[string]int{"db": 1, "missing": 1}, executor.Calls)
+}
+
+func TestFuncMapWithHTMLTemplate(t *testing.T) {
+	cli, err := New1Password(itemsExecutor(), OnePasswordOptions{})
+	assert.NoError(t, err)
+	tmpl := htmltemplate.Must(htmltemplate.New("page").Funcs(htmltemplate.FuncMap(cli.FuncMap())).Parse(
+		`<p>{{ op "op://vault/db/markup" }}</p>`))
+	var rendered bytes.Buffer
+
+	err = tmpl.Execute(&rendered, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "<p>&lt;hunter2&gt;</p>", rendered.String())
+}
+
+func TestFuncMapErrors(t *testing.T) {
+	cli, err := New1Password(itemsExecutor(), OnePasswordOptions{})
+	assert.NoError(t, err)
+
+	for _, text := range []string{
+		`{{ op "op://vault/db/missing" }}`,
+		`{{ opFile "op://vault/db/password" }}`,
+		`{{ opTOTP "op://vault/db/password" }}`,
+	} {
+		tmpl := template.Must(template.New("config").Funcs(cli.FuncMap()).Parse(text))
+		assert.Error(t, tmpl.Execute(&bytes.Buffer{}, nil), text)
+	}
+}
@@ -0,0 +1,100 @@
+package gonepassword
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// injectPlaceholderRegexp matches `{{ op://vault/item/field }}` placeholders, the syntax accepted by `op inject`.
+// The URI may contain ${VAR} references, whose closing brace does not end the placeholder.
+var injectPlaceholderRegexp = regexp.MustCompile(`\{\{\s*(op://(?:\$\{[^}]*\}|[^}])*?)\s*\}\}`)
+
+// Inject reads a template from r, replaces every `{{ op://vault/item/field }}` placeholder with its resolved value
+// and writes the result to w. All placeholders are resolved before anything is written, every item is fetched
+// only once.
+func (cli *OnePassword) Inject(ctx context.Context, r io.Reader, w io.Writer) error {
+	template, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("cannot read template: %w", err)
+	}
+	rendered, err := cli.renderInjectTemplate(ctx, template)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(rendered)
+	return err
+}
+
+// InjectFile works like Inject, but writes the result to the file at path with the given permissions.
+// The file is replaced atomically, so readers never see a partially rendered file and a failed render
+// leaves the previous file untouched.
+func (cli *OnePassword) InjectFile(ctx context.Context, r io.Reader, path string, perm os.FileMode) error {
+	template, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("cannot read template: %w", err)
+	}
+	rendered, err := cli.renderInjectTemplate(ctx, template)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, rendered, perm)
+}
+
+// renderInjectTemplate replaces all placeholders in the template with their resolved values.
+func (cli *OnePassword) renderInjectTemplate(ctx context.Context, template []byte) ([]byte, error) {
+	matches := injectPlaceholderRegexp.FindAllSubmatchIndex(template, -1)
+	if len(matches) == 0 {
+		return template, nil
+	}
+	uris := make([]string, 0, len(matches))
+	for _, match := range matches {
+		uris = append(uris, string(template[match[2]:match[3]]))
+	}
+	values, err := cli.ResolveMany(ctx, uris)
+	if err != nil {
+		return nil, err
+	}
+	var rendered bytes.Buffer
+	last := 0
+	for i, match := range matches {
+		rendered.Write(template[last:match[0]])
+		rendered.WriteString(values[uris[i]])
+		last = match[1]
+	}
+	rendered.Write(template[last:])
+	return rendered.Bytes(), nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it over path.
+// The temporary file is created with 0600 permissions, so the data is never readable by others
+// before perm is applied.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,72 @@
+package gonepassword
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const injectTemplate = `[databases]
+app = host=localhost user={{ op://vault/db/username }} password={{op://vault/db/password}}
+replica = user={{  op://vault/db/username  }} {{ not-an-op-uri }}
+`
+
+func TestInject(t *testing.T) {
+	executor := itemsExecutor()
+	cli, err := New1Password(executor, OnePasswordOptions{})
+	assert.NoError(t, err)
+	var rendered bytes.Buffer
+
+	err = cli.Inject(context.Background(), strings.NewReader(injectTemplate), &rendered)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `[databases]
+app = host=localhost user=admin password=hunter2
+replica = user=admin {{ not-an-op-uri }}
+`, rendered.String())
+	assert.Equal(t, map[string]int{"db": 1}, executor.Calls)
+}
+
+func TestInjectExpandsBracedVariables(t *testing.T) {
+	lookup := func(name string) (string, bool) { return "vault", name == "ENV" }
+	cli, err := New1Password(itemsExecutor(), OnePasswordOptions{LookupEnv: lookup})
+	assert.NoError(t, err)
+	var rendered bytes.Buffer
+
+	err = cli.Inject(context.Background(), strings.NewReader("{{ op://${ENV}/db/password }} {{op://$ENV/db/username}}"),
+		&rendered)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2 admin", rendered.String())
+}
+
+func TestInjectFile(t *testing.T) {
+	cli, err := New1Password(itemsExecutor(), OnePasswordOptions{})
+	assert.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "pgbouncer.ini")
+	assert.NoError(t, os.WriteFile(path, []byte("previous"), 0o644))
+
+	err = cli.InjectFile(context.Background(), strings.NewReader("password={{ op://vault/db/missing }}"), path, 0o600)
+	var resolveManyError *ResolveManyError
+	assert.True(t, errors.As(err, &resolveManyError))
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "previous", string(content))
+
+	err = cli.InjectFile(context.Background(), strings.NewReader("password={{ op://vault/db/password }}"), path, 0o640)
+	assert.NoError(t, err)
+	content, err = os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "password=hunter2", string(content))
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,204 @@
+package gonepassword
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Vault is a 1Password vault as listed by `op vault list`.
+type Vault struct {
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	ContentVersion int       `json:"content_version"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Item is a 1Password item as printed by `op item get --format json`.
+// Items returned by ListItems are overviews - their sections, fields and files are empty.
+type Item struct {
+	ID                    string        `json:"id"`
+	Title                 string        `json:"title"`
+	Category              string        `json:"category"`
+	Vault                 ItemVault     `json:"vault"`
+	Tags                  []string      `json:"tags,omitempty"`
+	URLs                  []ItemURL     `json:"urls,omitempty"`
+	Favorite              bool          `json:"favorite,omitempty"`
+	State                 string        `json:"state,omitempty"`
+	Version               int           `json:"version"`
+	AdditionalInformation string        `json:"additional_information,omitempty"`
+	LastEditedBy          string        `json:"last_edited_by,omitempty"`
+	CreatedAt             time.Time     `json:"created_at"`
+	UpdatedAt             time.Time     `json:"updated_at"`
+	Sections              []ItemSection `json:"sections,omitempty"`
+	Fields                []ItemField   `json:"fields,omitempty"`
+	Files                 []ItemFile    `json:"files,omitempty"`
+}
+
+// ItemVault is the vault an item belongs to.
+type ItemVault struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// ItemURL is a website an item is used on, e.g. the login page of a LOGIN item.
+type ItemURL struct {
+	Label   string `json:"label,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	HRef    string `json:"href"`
+}
+
+// ItemSection groups fields and files of an item. Fields outside of any section belong to
+// the built-in section with ID `add more`.
+type ItemSection struct {
+	ID    string `json:"id"`
+	Label string `json:"label,omitempty"`
+}
+
+// ItemField is a single field of an item. Purpose is USERNAME, PASSWORD or NOTES for the built-in fields
+// of a category, Reference is the op uri of the field.
+type ItemField struct {
+	ID              string           `json:"id"`
+	Type            string           `json:"type"`
+	Purpose         string           `json:"purpose,omitempty"`
+	Label           string           `json:"label"`
+	Value           string           `json:"value,omitempty"`
+	Reference       string           `json:"reference,omitempty"`
+	Entropy         float64          `json:"entropy,omitempty"`
+	PasswordDetails *PasswordDetails `json:"password_details,omitempty"`
+	Section         ItemSection      `json:"section,omitzero"`
+	SSHFormats      ItemSSHFormats   `json:"ssh_formats,omitzero"`
+}
+
+// PasswordDetails describes the strength and history of a password field.
+type PasswordDetails struct {
+	Entropy   float64  `json:"entropy,omitempty"`
+	Generated bool     `json:"generated,omitempty"`
+	Strength  string   `json:"strength,omitempty"`
+	History   []string `json:"history,omitempty"`
+}
+
+// ItemSSHFormats holds alternative formats of an SSH key field.
+type ItemSSHFormats struct {
+	OpenSSH ItemSSHFormat `json:"openssh,omitzero"`
+}
+
+// ItemSSHFormat is an SSH key in a single format.
+type ItemSSHFormat struct {
+	Value string `json:"value"`
+}
+
+// ItemFile is a file attached to an item, read its content with DownloadFile.
+type ItemFile struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Size        int64       `json:"size"`
+	ContentPath string      `json:"content_path,omitempty"`
+	Section     ItemSection `json:"section,omitzero"`
+}
+
+// clone returns a deep copy of the item, so callers cannot modify the cached item through the returned one.
+func (o Item) clone() Item {
+	o.Tags = slices.Clone(o.Tags)
+	o.URLs = slices.Clone(o.URLs)
+	o.Sections = slices.Clone(o.Sections)
+	o.Files = slices.Clone(o.Files)
+	o.Fields = slices.Clone(o.Fields)
+	for i, f := range o.Fields {
+		if f.PasswordDetails != nil {
+			details := *f.PasswordDetails
+			details.History = slices.Clone(details.History)
+			o.Fields[i].PasswordDetails = &details
+		}
+	}
+	return o
+}
+
+// ItemFilter narrows down items returned by ListItems, the zero value lists all active items.
+type ItemFilter struct {
+	// Categories keeps items of the given categories only, e.g. LOGIN or DATABASE.
+	Categories []string
+	// Tags keeps items with at least one of the given tags only.
+	Tags []string
+	// IncludeArchived lists archived items too.
+	IncludeArchived bool
+}
+
+// args returns the `op item list` arguments for the filter.
+func (f ItemFilter) args() []string {
+	var args []string
+	if len(f.Categories) > 0 {
+		args = append(args, "--categories", strings.Join(f.Categories, ","))
+	}
+	if len(f.Tags) > 0 {
+		args = append(args, "--tags", strings.Join(f.Tags, ","))
+	}
+	if f.IncludeArchived {
+		args = append(args, "--include-archive")
+	}
+	return args
+}
+
+// ListVaults returns all vaults accessible to the signed in account or service account.
+func (cli *OnePassword) ListVaults(ctx context.Context) ([]Vault, error) {
+	var vaults []Vault
+	if err := cli.executeJSON(ctx, &vaults, "vault", "list"); err != nil {
+		return nil, err
+	}
+	return vaults, nil
+}
+
+// ListItems returns overviews of items in the given vault matching filter. Empty vault lists items of all vaults.
+// Listing never fetches fields, use GetItem or ResolveOpURI for those.
+func (cli *OnePassword) ListItems(ctx context.Context, vault string, filter ItemFilter) ([]Item, error) {
+	args := []string{"item", "list"}
+	if vault != "" {
+		args = append(args, "--vault", vault)
+	}
+	var items []Item
+	if err := cli.executeJSON(ctx, &items, append(args, filter.args()...)...); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetItem returns the given item with all its sections, fields and files, vault and item may be given by name or ID.
+// The item is cached, so resolving its fields afterwards does not call the op CLI again.
+func (cli *OnePassword) GetItem(ctx context.Context, vault string, item string) (Item, error) {
+	if !cli.isInstalled {
+		return Item{}, &OnePasswordCliNotInstalledError{}
+	}
+	vaultItem, err := cli.fetchVaultItem(ctx, vault, item)
+	if err != nil {
+		return Item{}, err
+	}
+	return vaultItem.clone(), nil
+}
+
+// execute runs the given op command with the configured account.
+func (cli *OnePassword) execute(ctx context.Context, arg ...string) ([]byte, error) {
+	if !cli.isInstalled {
+		return nil, &OnePasswordCliNotInstalledError{}
+	}
+	return cli.executor.Execute(ctx, cli.withAccount(append([]string{}, arg...))...)
+}
+
+// executeJSON runs the given op command with JSON output and decodes the output into target.
+func (cli *OnePassword) executeJSON(ctx context.Context, target any, arg ...string) error {
+	output, err := cli.execute(ctx, append(append([]string{}, arg...), "--format", "json")...)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(output, target)
+}
+
+// withAccount appends the configured `--account` argument to the given op arguments.
+func (cli *OnePassword) withAccount(args []string) []string {
+	if cli.options.Account != "" {
+		args = append(args, "--account", cli.options.Account)
+	}
+	return args
+}
@@ -0,0 +1,180 @@
+package gonepassword
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestListVaults(t *testing.T) {
+	executor := &SpyCommandExecutor{IsCliInstalled: true, ExecuteOutput: []byte(`[
+		{"id": "v1", "name": "Private", "content_version": 42,
+		 "created_at": "2024-01-02T10:00:00Z", "updated_at": "2024-03-04T12:30:00Z"}
+	]`)}
+	cli, err := New1Password(executor, OnePasswordOptions{Account: "my.1password.com"})
+	assert.NoError(t, err)
+
+	vaults, err := cli.ListVaults(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Vault{{
+		ID:             "v1",
+		Name:           "Private",
+		ContentVersion: 42,
+		CreatedAt:      time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
+		UpdatedAt:      time.Date(2024, 3, 4, 12, 30, 0, 0, time.UTC),
+	}}, vaults)
+	assert.Equal(t, []string{"vault", "list", "--format", "json", "--account", "my.1password.com"}, executor.ExecuteArgs)
+}
+
+func TestListItems(t *testing.T) {
+	testCases := []struct {
+		name         string
+		vault        string
+		filter       ItemFilter
+		expectedArgs []string
+	}{
+		{
+			name:         "should list all items of all vaults",
+			expectedArgs: []string{"item", "list", "--format", "json"},
+		},
+		{
+			name:  "should pass vault and filter",
+			vault: "Private",
+			filter: ItemFilter{
+				Categories:      []string{"LOGIN", "DATABASE"},
+				Tags:            []string{"prod"},
+				IncludeArchived: true,
+			},
+			expectedArgs: []string{"item", "list", "--vault", "Private", "--categories", "LOGIN,DATABASE",
+				"--tags", "prod", "--include-archive", "--format", "json"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			executor := &SpyCommandExecutor{IsCliInstalled: true, ExecuteOutput: []byte(`[{
+				"id": "abc123", "title": "db", "version": 3, "category": "DATABASE",
+				"vault": {"id": "v1", "name": "Private"}, "tags": ["prod"],
+				"urls": [{"label": "website", "primary": true, "href": "https://db.example.com"}],
+				"last_edited_by": "U1", "additional_information": "admin",
+				"created_at": "2024-01-02T10:00:00Z", "updated_at": "2024-03-04T12:30:00Z"
+			}]`)}
+			cli, err := New1Password(executor, OnePasswordOptions{})
+			assert.NoError(t, err)
+
+			items, err := cli.ListItems(context.Background(), tc.vault, tc.filter)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedArgs, executor.ExecuteArgs)
+			assert.Equal(t, []Item{{
+				ID:                    "abc123",
+				Title:                 "db",
+				Category:              "DATABASE",
+				Vault:                 ItemVault{ID: "v1", Name: "Private"},
+				Tags:                  []string{"prod"},
+				URLs:                  []ItemURL{{Label: "website", Primary: true, HRef: "https://db.example.com"}},
+				Version:               3,
+				AdditionalInformation: "admin",
+				LastEditedBy:          "U1",
+				CreatedAt:             time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
+				UpdatedAt:             time.Date(2024, 3, 4, 12, 30, 0, 0, time.UTC),
+			}}, items)
+		})
+	}
+}
+
+func TestGetItem(t *testing.T) {
+	item := Item{ID: "abc123", Title: "db", Category: "DATABASE", Tags: []string{"prod"}, Version: 3,
+		Fields: []ItemField{{ID: "password", Label: "password", Value: "s3cret"}}}
+	executor := &ItemsCommandExecutor{Items: map[string]Item{"db": item}}
+	cli, err := New1Password(executor, OnePasswordOptions{})
+	assert.NoError(t, err)
+
+	fetched, err := cli.GetItem(context.Background(), "vault", "db")
+	assert.NoError(t, err)
+	assert.Equal(t, item, fetched)
+
+	fetched.Fields[0].Value = "changed"
+	fetched.Tags[0] = "changed"
+
+	value, err := cli.ResolveOpURI("op://vault/db/password")
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+	assert.Equal(t, map[string]int{"db": 1}, executor.Calls)
+}
+
+func TestListingRequiresCli(t *testing.T) {
+	cli, err := New1Password(&SpyCommandExecutor{}, OnePasswordOptions{})
+	assert.NoError(t, err)
+
+	var notInstalledError *OnePasswordCliNotInstalledError
+	_, err = cli.ListVaults(context.Background())
+	assert.ErrorAs(t, err, &notInstalledError)
+	_, err = cli.ListItems(context.Background(), "vault", ItemFilter{})
+	assert.ErrorAs(t, err, &notInstalledError)
+	_, err = cli.GetItem(context.Background(), "vault", "item")
+	assert.ErrorAs(t, err, &notInstalledError)
+}
+
+func TestGetItemDecodesFullSchema(t *testing.T) {
+	executor := &SpyCommandExecutor{IsCliInstalled: true, ExecuteOutput: []byte(`{
+		"id": "abc123", "title": "db", "version": 7, "category": "LOGIN", "favorite": true,
+		"vault": {"id": "v1", "name": "Private"}, "tags": ["prod"],
+		"urls": [{"label": "website", "primary": true, "href": "https://db.example.com"}],
+		"last_edited_by": "U1", "created_at": "2024-01-02T10:00:00Z", "updated_at": "2024-03-04T12:30:00Z",
+		"additional_information": "admin",
+		"sections": [{"id": "add more"}, {"id": "s1", "label": "replica"}],
+		"fields": [
+			{"id": "username", "type": "STRING", "purpose": "USERNAME", "label": "username", "value": "admin",
+			 "reference": "op://Private/db/username"},
+			{"id": "password", "type": "CONCEALED", "purpose": "PASSWORD", "label": "password", "value": "s3cret",
+			 "entropy": 115.4, "reference": "op://Private/db/password",
+			 "password_details": {"entropy": 115, "generated": true, "strength": "FANTASTIC", "history": ["old"]}},
+			{"id": "host", "type": "STRING", "label": "host", "value": "replica.example.com",
+			 "section": {"id": "s1", "label": "replica"}, "reference": "op://Private/db/replica/host"}
+		],
+		"files": [{"id": "f1", "name": "ca.pem", "size": 1024, "content_path": "/v1/vaults/v1/items/abc123/files/f1/content",
+			"section": {"id": "s1", "label": "replica"}}]
+	}`)}
+	cli, err := New1Password(executor, OnePasswordOptions{})
+	assert.NoError(t, err)
+
+	item, err := cli.GetItem(context.Background(), "Private", "db")
+
+	assert.NoError(t, err)
+	replica := ItemSection{ID: "s1", Label: "replica"}
+	assert.Equal(t, Item{
+		ID:                    "abc123",
+		Title:                 "db",
+		Category:              "LOGIN",
+		Vault:                 ItemVault{ID: "v1", Name: "Private"},
+		Tags:                  []string{"prod"},
+		URLs:                  []ItemURL{{Label: "website", Primary: true, HRef: "https://db.example.com"}},
+		Favorite:              true,
+		Version:               7,
+		AdditionalInformation: "admin",
+		LastEditedBy:          "U1",
+		CreatedAt:             time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC),
+		UpdatedAt:             time.Date(2024, 3, 4, 12, 30, 0, 0, time.UTC),
+		Sections:              []ItemSection{{ID: "add more"}, replica},
+		Fields: []ItemField{
+			{ID: "username", Type: "STRING", Purpose: "USERNAME", Label: "username", Value: "admin",
+				Reference: "op://Private/db/username"},
+			{ID: "password", Type: "CONCEALED", Purpose: "PASSWORD", Label: "password", Value: "s3cret",
+				Entropy: 115.4, Reference: "op://Private/db/password",
+				PasswordDetails: &PasswordDetails{Entropy: 115, Generated: true, Strength: "FANTASTIC",
+					History: []string{"old"}}},
+			{ID: "host", Type: "STRING", Label: "host", Value: "replica.example.com", Section: replica,
+				Reference: "op://Private/db/replica/host"},
+		},
+		Files: []ItemFile{{ID: "f1", Name: "ca.pem", Size: 1024,
+			ContentPath: "/v1/vaults/v1/items/abc123/files/f1/content", Section: replica}},
+	}, item)
+
+	item.Fields[1].PasswordDetails.History[0] = "changed"
+	cached, err := cli.GetItem(context.Background(), "Private", "db")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"old"}, cached.Fields[1].PasswordDetails.History)
+}
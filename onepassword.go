@@ -2,23 +2,30 @@
 package gonepassword
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-	"github.com/sirupsen/logrus"
+	"io"
+	"log/slog"
 	"strings"
+	"time"
 )
 
-// OnePasswordClient is an interface for fetching secrets from 1Password.
+// OnePasswordClient is an interface for fetching secrets and browsing items in 1Password.
 type OnePasswordClient interface {
 	ResolveOpURI(uri string) (string, error)
+	ResolveOpURIContext(ctx context.Context, uri string) (string, error)
+	ListVaults(ctx context.Context) ([]Vault, error)
+	ListItems(ctx context.Context, vault string, filter ItemFilter) ([]Item, error)
+	GetItem(ctx context.Context, vault string, item string) (Item, error)
 }
 
 // OnePassword is a wrapper around the 1Password CLI.
 type OnePassword struct {
 	executor CommandExecutor
-	opStorage
+	*opStorage
 	isInstalled bool
 	options     OnePasswordOptions
+	logger      *slog.Logger
 }
 
 // OnePasswordOptions is a struct that holds the options for the 1Password client.
@@ -27,87 +34,134 @@ type OnePasswordOptions struct {
 	ServiceAccountToken string
 	// Account is the `--account` op cli argument to use when fetching secrets.
 	Account string
-}
-
-// OpURI is a struct that holds the parsed 1Password URI.
-type OpURI struct {
-	vault   string
-	item    string
-	field   string
-	section string
-	//query   string // TODO query support some day
-	raw string
-}
-
-// NewOpURI creates a new OpURI instance.
-func NewOpURI(uri string) (*OpURI, error) {
-	parts := strings.Split(strings.TrimPrefix(uri, opURIPrefix), "/")
-	numParts := len(parts)
-	var opURI OpURI
-	if numParts < 3 || numParts > 4 {
-		return nil, fmt.Errorf("invalid 1Password URI format - expected op://vault/item/field - got '%s'", uri)
-	}
-	opURI = OpURI{raw: uri, vault: parts[0], item: parts[1], field: parts[2], section: ""}
-	if numParts == 4 {
-		opURI.section = parts[2]
-		opURI.field = parts[3]
-	}
-	return &opURI, nil
+	// RetryPolicy controls how failed op cli calls are retried, DefaultRetryPolicy is used when left empty.
+	RetryPolicy RetryPolicy
+	// CacheTTL is how long a fetched item is reused before it is fetched again, zero means forever.
+	CacheTTL time.Duration
+	// Logger receives structured logs of the client and the default executor, slog.Default() is used when nil.
+	Logger *slog.Logger
+	// LookupEnv expands $VAR and ${VAR} references in op uris, os.LookupEnv is used when nil.
+	LookupEnv func(name string) (string, bool)
+	// Stderr receives stderr output of op cli calls made by the default executor, it is discarded when nil.
+	Stderr io.Writer
+	// MaxFileSize is the largest file or document in bytes DownloadFile and GetDocument accept, zero means no limit.
+	MaxFileSize int64
 }
 
 const binName string = "op"
 const opURIPrefix string = "op://"
 const serviceAccountTokenEnv = "OP_SERVICE_ACCOUNT_TOKEN" //nolint
-const retryAttempts = 5
+
+// loggerOrDefault returns the given logger, or slog.Default() when it is nil.
+func loggerOrDefault(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+	return logger
+}
 
 // New1Password creates a new OnePassword instance.
 // serviceAccountToken can be passed directly to constructor, or it will be read from environment variable.
 func New1Password(executor CommandExecutor, options OnePasswordOptions) (*OnePassword, error) {
 	if executor == nil {
-		executor = DefaultCommandExecutor{options.ServiceAccountToken}
+		executor = DefaultCommandExecutor{
+			serviceAccountToken: options.ServiceAccountToken,
+			retryPolicy:         options.RetryPolicy,
+			logger:              options.Logger,
+			stderr:              options.Stderr,
+		}
+	}
+	opCli := &OnePassword{
+		executor:  executor,
+		opStorage: newOPStorage(options.CacheTTL),
+		options:   options,
+		logger:    loggerOrDefault(options.Logger),
 	}
-	opCli := &OnePassword{executor: executor, opStorage: newOPStorage(), options: options}
 	opCli.isInstalled = opCli.executor.IsInstalled()
 	return opCli, nil
 }
 
+// newOpURI parses the given uri, expanding variables with the configured lookup function.
+func (cli *OnePassword) newOpURI(uri string) (*OpURI, error) {
+	if cli.options.LookupEnv != nil {
+		return NewOpURIWithLookup(uri, cli.options.LookupEnv)
+	}
+	return NewOpURI(uri)
+}
+
 // ResolveOpURI resolves the given 1Password URI and returns its value.
 // It also caches whole uri item in memory to avoid multiple calls to 1Password CLI
 // while fetching other fields from the same item.
 func (cli *OnePassword) ResolveOpURI(uri string) (string, error) {
+	return cli.ResolveOpURIContext(context.Background(), uri)
+}
+
+// ResolveOpURIContext works like ResolveOpURI, but gives up as soon as ctx is cancelled or its deadline passes.
+// A running op process is killed and any pending retry backoff is abandoned.
+func (cli *OnePassword) ResolveOpURIContext(ctx context.Context, uri string) (string, error) {
 	if !strings.HasPrefix(uri, opURIPrefix) {
 		return uri, &InvalidOpURIError{uri: uri}
 	}
-	logrus.Info("Resolving 1password entry: ", uri)
-	opURI, err := NewOpURI(uri)
+	opURI, err := cli.newOpURI(uri)
 	if err != nil {
 		return "", err
 	}
+	cli.logger.DebugContext(ctx, "resolving 1Password uri", "vault", opURI.vault, "item", opURI.item,
+		"section", opURI.section, "field", opURI.field)
 	if !cli.isInstalled {
-		logrus.Error(&OnePasswordCliNotInstalledError{})
+		cli.logger.ErrorContext(ctx, "1Password CLI is not installed")
 		return "", &OnePasswordCliNotInstalledError{}
 	}
-	vaultItem, err := cli.getVaultItem(opURI.vault, opURI.item)
+	vaultItem, err := cli.fetchVaultItem(ctx, opURI.vault, opURI.item)
 	if err != nil {
-		executorCmd := []string{"item", "get", "--format", "json", opURI.item, "--vault", opURI.vault}
-		if cli.options.Account != "" {
-			executorCmd = append(executorCmd, "--account", cli.options.Account)
-		}
-		output, err := cli.executor.Execute(executorCmd...)
-		if err != nil {
-			return "", err
-		}
-		var opItem opItem
-		err = json.Unmarshal(output, &opItem)
-		if err != nil {
-			return "", err
-		}
-		cli.setVaultItem(opURI.vault, opURI.item, opItem)
-		vaultItem = opItem
+		return "", err
 	}
-	fieldValue, err := vaultItem.GetFieldValue(cli, opURI)
+	fieldValue, err := vaultItem.GetFieldValue(ctx, cli, opURI)
 	if err != nil {
 		return "", err
 	}
 	return fieldValue, nil
 }
+
+// fetchVaultItem returns the given item from the cache, fetching it through the op CLI on a cache miss.
+// Concurrent calls for the same item spawn a single op process.
+func (cli *OnePassword) fetchVaultItem(ctx context.Context, vault string, item string) (Item, error) {
+	return cli.getOrFetchVaultItem(ctx, vault, item, func() (Item, error) {
+		return cli.getItemFromCli(ctx, vault, item)
+	})
+}
+
+// getItemFromCli fetches the given item through the op CLI, bypassing the cache.
+func (cli *OnePassword) getItemFromCli(ctx context.Context, vault string, item string) (Item, error) {
+	executorCmd := cli.withAccount([]string{"item", "get", "--format", "json", item, "--vault", vault})
+	start := time.Now()
+	output, err := cli.executor.Execute(ctx, executorCmd...)
+	if err != nil {
+		cli.logger.DebugContext(ctx, "fetching 1Password item failed", "vault", vault, "item", item,
+			"duration", time.Since(start), "error", err)
+		return Item{}, err
+	}
+	cli.logger.DebugContext(ctx, "fetched 1Password item", "vault", vault, "item", item, "duration", time.Since(start))
+	var vaultItem Item
+	err = json.Unmarshal(output, &vaultItem)
+	if err != nil {
+		return Item{}, err
+	}
+	return vaultItem, nil
+}
+
+// InvalidateItem drops the given item from the cache, so it is fetched again on next use.
+// vault and item may be given by name or ID, a title shared by several items drops all of them.
+func (cli *OnePassword) InvalidateItem(vault string, item string) {
+	cli.invalidateItem(vault, item)
+}
+
+// InvalidateVault drops all cached items of the given vault.
+func (cli *OnePassword) InvalidateVault(vault string) {
+	cli.invalidateVault(vault)
+}
+
+// Purge drops all cached items.
+func (cli *OnePassword) Purge() {
+	cli.purge()
+}
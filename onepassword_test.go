@@ -1,9 +1,12 @@
 package gonepassword
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"github.com/stretchr/testify/assert"
+	"log/slog"
 	"testing"
 )
 
@@ -19,16 +22,16 @@ func (e *SpyCommandExecutor) IsInstalled() bool {
 	return e.IsCliInstalled
 }
 
-func (e *SpyCommandExecutor) Execute(arg ...string) ([]byte, error) {
+func (e *SpyCommandExecutor) Execute(_ context.Context, arg ...string) ([]byte, error) {
 	e.ExecuteArgs = arg
 	e.IsExecuteCalled = true
 	return e.ExecuteOutput, e.ExecuteError
 }
 
 func TestResolveOpURI(t *testing.T) { //nolint
-	opItem := opItem{
+	opItem := Item{
 		ID: "item",
-		Fields: []opField{
+		Fields: []ItemField{
 			{
 				ID:    "field-id",
 				Label: "field-label",
@@ -143,9 +146,9 @@ func TestResolveOpURI(t *testing.T) { //nolint
 }
 
 func Test1PasswordStorage(t *testing.T) {
-	opItem := opItem{
+	opItem := Item{
 		ID: "item",
-		Fields: []opField{
+		Fields: []ItemField{
 			{
 				ID:    "field-id",
 				Label: "field-label",
@@ -210,59 +213,51 @@ func Test1PasswordStorage(t *testing.T) {
 	}
 }
 
-func TestNewOpURI(t *testing.T) {
-	testCases := []struct {
-		name          string
-		uri           string
-		expectedError string
-		expectedOpURI *OpURI
-	}{
-		{
-			name:          "should return error when uri is too short",
-			uri:           "op://vault/item",
-			expectedError: "invalid 1Password URI format - expected op://vault/item/field - got 'op://vault/item'",
-		},
-		{
-			name: "should return error when uri is too long",
-			uri:  "op://vault/item/section/field/extra",
-			expectedError: "invalid 1Password URI format - expected op://vault/item/field - " +
-				"got 'op://vault/item/section/field/extra'",
-		},
-		{
-			name: "should return valid OpURI when uri is valid with three parts",
-			uri:  "op://vault/item/field",
-			expectedOpURI: &OpURI{
-				raw:     "op://vault/item/field",
-				vault:   "vault",
-				item:    "item",
-				field:   "field",
-				section: "",
-			},
-		},
-		{
-			name: "should return valid OpURI when uri is valid with four parts",
-			uri:  "op://vault/item/section/field",
-			expectedOpURI: &OpURI{
-				raw:     "op://vault/item/section/field",
-				vault:   "vault",
-				item:    "item",
-				field:   "field",
-				section: "section",
-			},
-		},
-	}
+func TestResolveOpURILogsToProvidedLogger(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	cli, err := New1Password(&SpyCommandExecutor{IsCliInstalled: false}, OnePasswordOptions{Logger: logger})
+	assert.NoError(t, err)
 
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			result, err := NewOpURI(tc.uri)
+	_, err = cli.ResolveOpURI("op://vault/item/section/field")
 
-			if tc.expectedError != "" {
-				assert.Error(t, err)
-				assert.Equal(t, tc.expectedError, err.Error())
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tc.expectedOpURI, result)
-			}
-		})
+	assert.Error(t, err)
+	assert.Contains(t, logs.String(),
+		`level=DEBUG msg="resolving 1Password uri" vault=vault item=item section=section field=field`)
+	assert.Contains(t, logs.String(), `level=ERROR msg="1Password CLI is not installed"`)
+}
+
+func TestResolveOpURIExpandsVariables(t *testing.T) {
+	t.Setenv("GONEPASSWORD_TEST_VAULT", "from-env")
+	executor := &ItemsCommandExecutor{Items: map[string]Item{
+		"db": {ID: "db", Fields: []ItemField{{ID: "password", Value: "hunter2"}}},
+	}}
+
+	cli, err := New1Password(executor, OnePasswordOptions{})
+	assert.NoError(t, err)
+	_, err = cli.ResolveOpURI("op://${GONEPASSWORD_TEST_VAULT}/db/password")
+	assert.NoError(t, err)
+
+	cli, err = New1Password(executor, OnePasswordOptions{LookupEnv: func(name string) (string, bool) {
+		return "from-lookup", name == "VAULT"
+	}})
+	assert.NoError(t, err)
+	values, err := cli.ResolveMany(context.Background(), []string{"op://$VAULT/db/password"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"op://$VAULT/db/password": "hunter2"}, values)
+}
+
+func TestResolveOpURIReusesItemFetchedByNameWhenReferencedByID(t *testing.T) {
+	item := Item{ID: "abc123", Title: "db", Vault: ItemVault{ID: "v1", Name: "Private"},
+		Fields: []ItemField{{ID: "password", Label: "password", Value: "s3cret"}}}
+	executor := &ItemsCommandExecutor{Items: map[string]Item{"db": item, "abc123": item}}
+	cli, err := New1Password(executor, OnePasswordOptions{})
+	assert.NoError(t, err)
+
+	for _, uri := range []string{"op://Private/db/password", "op://v1/abc123/password", "op://Private/abc123/password"} {
+		value, err := cli.ResolveOpURI(uri)
+		assert.NoError(t, err)
+		assert.Equal(t, "s3cret", value)
 	}
+	assert.Equal(t, map[string]int{"db": 1}, executor.Calls)
 }
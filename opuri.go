@@ -0,0 +1,232 @@
+package gonepassword
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// OpURI is a struct that holds the parsed 1Password URI.
+type OpURI struct {
+	vault     string
+	item      string
+	field     string
+	section   string
+	attribute string
+	sshFormat string
+	raw       string
+}
+
+// Supported values of the `attribute` query parameter.
+const (
+	attributeValue   = "value"
+	attributeType    = "type"
+	attributeID      = "id"
+	attributeTitle   = "title"
+	attributePurpose = "purpose"
+	attributeOTP     = "otp"
+)
+
+// sshFormatOpenSSH is the only supported value of the `ssh-format` query parameter.
+const sshFormatOpenSSH = "openssh"
+
+// NewOpURI creates a new OpURI instance.
+// Vault, item, section and field names are percent-decoded, so names containing `/` or `?` can be addressed
+// as %2F and %3F, and a literal `%` has to be written as %25. Parts which are not validly percent-encoded,
+// like `100%`, are taken as they are.
+// Query parameters `attribute` (value, type, id, title, purpose or otp) and `ssh-format` (openssh) are supported.
+// $VAR and ${VAR} references are expanded from the process environment, e.g. op://${ENV}-secrets/db/password.
+// A literal `$` followed by a variable name has to be written as $$ or %24, e.g. op://vault/db/pa$$word.
+func NewOpURI(uri string) (*OpURI, error) {
+	return NewOpURIWithLookup(uri, os.LookupEnv)
+}
+
+// NewOpURIWithLookup works like NewOpURI, but expands variable references with the given lookup function.
+// Referencing a variable the lookup function does not know is an UndefinedVariableError.
+func NewOpURIWithLookup(uri string, lookup func(name string) (string, bool)) (*OpURI, error) {
+	uri, err := expandOpURIVariables(uri, lookup)
+	if err != nil {
+		return nil, err
+	}
+	return parseOpURI(uri)
+}
+
+// parseOpURI parses the given uri without expanding variable references, for values which have been
+// interpolated already, like those of .env files or process environment variables.
+func parseOpURI(uri string) (*OpURI, error) {
+	path, rawQuery, _ := strings.Cut(strings.TrimPrefix(uri, opURIPrefix), "?")
+	parts := strings.Split(path, "/")
+	numParts := len(parts)
+	var opURI OpURI
+	if numParts < 3 || numParts > 4 {
+		return nil, fmt.Errorf("invalid 1Password URI format - expected op://vault/item/field - got '%s'", uri)
+	}
+	for i, part := range parts {
+		// parts which are not validly escaped are names written before escaping was supported, like `100%`
+		if unescaped, err := url.PathUnescape(part); err == nil {
+			parts[i] = unescaped
+		}
+	}
+	opURI = OpURI{raw: uri, vault: parts[0], item: parts[1], field: parts[2], section: ""}
+	if numParts == 4 {
+		opURI.section = parts[2]
+		opURI.field = parts[3]
+	}
+	if err := opURI.parseQuery(rawQuery); err != nil {
+		return nil, err
+	}
+	return &opURI, nil
+}
+
+// expandOpURIVariables replaces $VAR and ${VAR} references in the uri with values returned by lookup.
+// $$ is an escaped $, a $ which does not start a variable name is kept as is.
+func expandOpURIVariables(uri string, lookup func(name string) (string, bool)) (string, error) {
+	if !strings.Contains(uri, "$") {
+		return uri, nil
+	}
+	var expanded strings.Builder
+	for i := 0; i < len(uri); i++ {
+		if uri[i] != '$' {
+			expanded.WriteByte(uri[i])
+			continue
+		}
+		var name string
+		end := i + 1
+		if end < len(uri) && uri[end] == '$' {
+			expanded.WriteByte('$')
+			i = end
+			continue
+		}
+		if end < len(uri) && uri[end] == '{' {
+			closing := strings.IndexByte(uri[end:], '}')
+			if closing < 0 || !isVariableName(uri[end+1:end+closing]) {
+				return "", fmt.Errorf("invalid variable reference in op uri '%s'", uri)
+			}
+			name = uri[end+1 : end+closing]
+			end += closing + 1
+		} else {
+			for end < len(uri) && isVariableChar(uri[end], end == i+1) {
+				end++
+			}
+			name = uri[i+1 : end]
+		}
+		if name == "" {
+			expanded.WriteByte('$')
+			continue
+		}
+		value, ok := lookup(name)
+		if !ok {
+			return "", &UndefinedVariableError{URI: uri, Name: name}
+		}
+		expanded.WriteString(value)
+		i = end - 1
+	}
+	return expanded.String(), nil
+}
+
+// parseQuery parses and validates the query part of the 1Password URI.
+func (u *OpURI) parseQuery(rawQuery string) error {
+	if rawQuery == "" {
+		return nil
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return fmt.Errorf("invalid 1Password URI query in '%s': %w", u.raw, err)
+	}
+	for key, values := range query {
+		if len(values) != 1 {
+			return fmt.Errorf("invalid 1Password URI query in '%s' - parameter %s given more than once", u.raw, key)
+		}
+		value := values[0]
+		switch key {
+		case "attribute", "attr":
+			switch value {
+			case attributeValue, attributeType, attributeID, attributeTitle, attributePurpose, attributeOTP:
+				u.attribute = value
+			default:
+				return fmt.Errorf("invalid 1Password URI query in '%s' - unsupported attribute %s", u.raw, value)
+			}
+		case "ssh-format":
+			if value != sshFormatOpenSSH {
+				return fmt.Errorf("invalid 1Password URI query in '%s' - unsupported ssh-format %s", u.raw, value)
+			}
+			u.sshFormat = value
+		default:
+			return fmt.Errorf("invalid 1Password URI query in '%s' - unsupported parameter %s", u.raw, key)
+		}
+	}
+	return nil
+}
+
+// BuildOpURI creates an OpURI from its parts, section is optional. Parts may contain any characters,
+// they are escaped as needed by String.
+func BuildOpURI(vault string, item string, section string, field string) (*OpURI, error) {
+	if vault == "" || item == "" || field == "" {
+		return nil, fmt.Errorf("invalid 1Password URI parts - vault, item and field are required")
+	}
+	opURI := &OpURI{vault: vault, item: item, section: section, field: field}
+	opURI.raw = opURI.String()
+	return opURI, nil
+}
+
+// Vault returns the vault name or ID.
+func (u *OpURI) Vault() string {
+	return u.vault
+}
+
+// Item returns the item name or ID.
+func (u *OpURI) Item() string {
+	return u.item
+}
+
+// Section returns the section name or ID, empty when the uri has no section.
+func (u *OpURI) Section() string {
+	return u.section
+}
+
+// Field returns the field or file name or ID.
+func (u *OpURI) Field() string {
+	return u.field
+}
+
+// Attribute returns the value of the `attribute` query parameter.
+func (u *OpURI) Attribute() string {
+	return u.attribute
+}
+
+// SSHFormat returns the value of the `ssh-format` query parameter.
+func (u *OpURI) SSHFormat() string {
+	return u.sshFormat
+}
+
+// String returns the uri with its parts escaped, so NewOpURI parses it back into the same parts.
+func (u *OpURI) String() string {
+	parts := []string{u.vault, u.item, u.section, u.field}
+	if u.section == "" {
+		parts = []string{u.vault, u.item, u.field}
+	}
+	for i, part := range parts {
+		parts[i] = escapeOpURIPart(part)
+	}
+	var query []string
+	if u.attribute != "" {
+		query = append(query, "attribute="+u.attribute)
+	}
+	if u.sshFormat != "" {
+		query = append(query, "ssh-format="+u.sshFormat)
+	}
+	uri := opURIPrefix + strings.Join(parts, "/")
+	if len(query) > 0 {
+		uri += "?" + strings.Join(query, "&")
+	}
+	return uri
+}
+
+// opURIPartEscaper escapes only characters with a meaning in op uris, leaving names readable
+// and understood by the op cli otherwise.
+var opURIPartEscaper = strings.NewReplacer("%", "%25", "/", "%2F", "?", "%3F", "$", "%24")
+
+func escapeOpURIPart(part string) string {
+	return opURIPartEscaper.Replace(part)
+}
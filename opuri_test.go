@@ -0,0 +1,263 @@
+package gonepassword
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewOpURI(t *testing.T) {
+	testCases := []struct {
+		name          string
+		uri           string
+		expectedError string
+		expectedOpURI *OpURI
+	}{
+		{
+			name:          "should return error when uri is too short",
+			uri:           "op://vault/item",
+			expectedError: "invalid 1Password URI format - expected op://vault/item/field - got 'op://vault/item'",
+		},
+		{
+			name: "should return error when uri is too long",
+			uri:  "op://vault/item/section/field/extra",
+			expectedError: "invalid 1Password URI format - expected op://vault/item/field - " +
+				"got 'op://vault/item/section/field/extra'",
+		},
+		{
+			name: "should return valid OpURI when uri is valid with three parts",
+			uri:  "op://vault/item/field",
+			expectedOpURI: &OpURI{
+				raw:     "op://vault/item/field",
+				vault:   "vault",
+				item:    "item",
+				field:   "field",
+				section: "",
+			},
+		},
+		{
+			name: "should return valid OpURI when uri is valid with four parts",
+			uri:  "op://vault/item/section/field",
+			expectedOpURI: &OpURI{
+				raw:     "op://vault/item/section/field",
+				vault:   "vault",
+				item:    "item",
+				field:   "field",
+				section: "section",
+			},
+		},
+		{
+			name: "should parse attribute query parameter",
+			uri:  "op://vault/item/one-time password?attribute=otp",
+			expectedOpURI: &OpURI{
+				raw:       "op://vault/item/one-time password?attribute=otp",
+				vault:     "vault",
+				item:      "item",
+				field:     "one-time password",
+				attribute: "otp",
+			},
+		},
+		{
+			name: "should parse ssh-format query parameter",
+			uri:  "op://vault/item/private key?ssh-format=openssh",
+			expectedOpURI: &OpURI{
+				raw:       "op://vault/item/private key?ssh-format=openssh",
+				vault:     "vault",
+				item:      "item",
+				field:     "private key",
+				sshFormat: "openssh",
+			},
+		},
+		{
+			name: "should percent-decode uri parts",
+			uri:  "op://vault/CI%2FCD/100%25 key%3F",
+			expectedOpURI: &OpURI{
+				raw:   "op://vault/CI%2FCD/100%25 key%3F",
+				vault: "vault",
+				item:  "CI/CD",
+				field: "100% key?",
+			},
+		},
+		{
+			name: "should keep parts which are not validly escaped",
+			uri:  "op://vault/50%off/100%",
+			expectedOpURI: &OpURI{
+				raw:   "op://vault/50%off/100%",
+				vault: "vault",
+				item:  "50%off",
+				field: "100%",
+			},
+		},
+		{
+			name: "should return error on unsupported attribute",
+			uri:  "op://vault/item/field?attribute=color",
+			expectedError: "invalid 1Password URI query in 'op://vault/item/field?attribute=color' - " +
+				"unsupported attribute color",
+		},
+		{
+			name: "should return error on unsupported query parameter",
+			uri:  "op://vault/item/field?color=red",
+			expectedError: "invalid 1Password URI query in 'op://vault/item/field?color=red' - " +
+				"unsupported parameter color",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := NewOpURI(tc.uri)
+
+			if tc.expectedError != "" {
+				assert.Error(t, err)
+				assert.Equal(t, tc.expectedError, err.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedOpURI, result)
+			}
+		})
+	}
+}
+
+func TestNewOpURIWithLookup(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		value, ok := map[string]string{"ENV": "prod", "REGION": "eu", "EMPTY": ""}[name]
+		return value, ok
+	}
+
+	testCases := []struct {
+		name          string
+		uri           string
+		expectedRaw   string
+		expectedError string
+	}{
+		{
+			name:        "should expand braced and bare variables",
+			uri:         "op://${ENV}-secrets/db-$REGION/password",
+			expectedRaw: "op://prod-secrets/db-eu/password",
+		},
+		{
+			name:        "should expand empty variables",
+			uri:         "op://vault/item${EMPTY}/password",
+			expectedRaw: "op://vault/item/password",
+		},
+		{
+			name:        "should keep a dollar sign which does not start a variable",
+			uri:         "op://vault/item/pa$-word",
+			expectedRaw: "op://vault/item/pa$-word",
+		},
+		{
+			name:        "should unescape a doubled dollar sign",
+			uri:         "op://vault/item/pa$$word$$$$",
+			expectedRaw: "op://vault/item/pa$word$$",
+		},
+		{
+			name:        "should not expand a percent-encoded dollar sign",
+			uri:         "op://vault/item/pa%24word",
+			expectedRaw: "op://vault/item/pa%24word",
+		},
+		{
+			name:          "should return error on undefined variable",
+			uri:           "op://${STAGE}-secrets/db/password",
+			expectedError: "op uri op://${STAGE}-secrets/db/password references undefined variable STAGE",
+		},
+		{
+			name:          "should return error on unterminated variable",
+			uri:           "op://${ENV-secrets/db/password",
+			expectedError: "invalid variable reference in op uri 'op://${ENV-secrets/db/password'",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opURI, err := NewOpURIWithLookup(tc.uri, lookup)
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedRaw, opURI.raw)
+			}
+		})
+	}
+
+	var undefinedVariableError *UndefinedVariableError
+	_, err := NewOpURIWithLookup("op://${STAGE}/db/password", lookup)
+	assert.ErrorAs(t, err, &undefinedVariableError)
+	assert.Equal(t, "STAGE", undefinedVariableError.Name)
+}
+
+func TestBuildOpURI(t *testing.T) {
+	testCases := []struct {
+		name          string
+		vault         string
+		item          string
+		section       string
+		field         string
+		expectedURI   string
+		expectedError string
+	}{
+		{
+			name:        "should build uri without section",
+			vault:       "vault",
+			item:        "item",
+			field:       "password",
+			expectedURI: "op://vault/item/password",
+		},
+		{
+			name:        "should build uri with section",
+			vault:       "vault",
+			item:        "item",
+			section:     "database",
+			field:       "password",
+			expectedURI: "op://vault/item/database/password",
+		},
+		{
+			name:        "should escape reserved characters only",
+			vault:       "Private Vault",
+			item:        "CI/CD",
+			section:     "what?",
+			field:       "100% $HOME",
+			expectedURI: "op://Private Vault/CI%2FCD/what%3F/100%25 %24HOME",
+		},
+		{
+			name:          "should return error when field is missing",
+			vault:         "vault",
+			item:          "item",
+			expectedError: "invalid 1Password URI parts - vault, item and field are required",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opURI, err := BuildOpURI(tc.vault, tc.item, tc.section, tc.field)
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedURI, opURI.String())
+
+			parsed, err := NewOpURIWithLookup(opURI.String(), func(string) (string, bool) { return "", false })
+			assert.NoError(t, err)
+			assert.Equal(t, tc.vault, parsed.Vault())
+			assert.Equal(t, tc.item, parsed.Item())
+			assert.Equal(t, tc.section, parsed.Section())
+			assert.Equal(t, tc.field, parsed.Field())
+		})
+	}
+}
+
+func TestOpURIStringRoundTrips(t *testing.T) {
+	uris := []string{
+		"op://vault/item/field",
+		"op://vault/item/section/field",
+		"op://vault/CI%2FCD/100%25 key%3F",
+		"op://vault/item/one-time password?attribute=otp",
+		"op://vault/item/private key?attribute=value&ssh-format=openssh",
+	}
+
+	for _, uri := range uris {
+		opURI, err := NewOpURI(uri)
+		assert.NoError(t, err)
+		assert.Equal(t, uri, opURI.String())
+	}
+}
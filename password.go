@@ -0,0 +1,310 @@
+package gonepassword
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// PasswordRecipe describes how to generate a password or a passphrase.
+// The zero value is a 32 character password made of letters, digits and symbols, like the op CLI default.
+type PasswordRecipe struct {
+	// Length of the password, 32 when zero. The op CLI supports 1 to 64 characters.
+	Length int
+	// Letters, Digits and Symbols select the character classes used, all of them when none is set.
+	// Every selected class is used at least once.
+	Letters bool
+	Digits  bool
+	Symbols bool
+	// ExcludeCharacters are never used in the password, e.g. characters that need escaping in a connection string.
+	ExcludeCharacters string
+	// Words generates a passphrase of that many words picked from WordList instead of a password.
+	Words    int
+	WordList []string
+	// Separator joins the words of a passphrase, "-" when empty.
+	Separator string
+}
+
+const (
+	defaultPasswordLength = 32
+	maxPasswordLength     = 64
+	concealedFieldType    = "CONCEALED"
+	passwordPurpose       = "PASSWORD"
+)
+
+// Character classes of generated passwords.
+const (
+	passwordLetters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	passwordDigits  = "0123456789"
+	passwordSymbols = "!@#$%^&*-_=+.,?~"
+)
+
+// validate reports recipes which cannot produce a password.
+func (r PasswordRecipe) validate() error {
+	if r.Words > 0 {
+		if len(r.WordList) == 0 {
+			return errors.New("invalid password recipe - a passphrase needs a word list")
+		}
+		return nil
+	}
+	if r.Length < 0 || r.Length > maxPasswordLength {
+		return fmt.Errorf("invalid password recipe - length must be between 1 and %d - got %d",
+			maxPasswordLength, r.Length)
+	}
+	classes := r.classes()
+	for _, class := range classes {
+		if class == "" {
+			return errors.New("invalid password recipe - excluded characters leave a character class empty")
+		}
+	}
+	if r.length() < len(classes) {
+		return fmt.Errorf("invalid password recipe - length %d is too short to use %d character classes",
+			r.length(), len(classes))
+	}
+	return nil
+}
+
+// selectedClasses returns which character classes are used, with the default applied.
+func (r PasswordRecipe) selectedClasses() (letters bool, digits bool, symbols bool) {
+	if !r.Letters && !r.Digits && !r.Symbols {
+		return true, true, true
+	}
+	return r.Letters, r.Digits, r.Symbols
+}
+
+// length returns the password length, with the default applied.
+func (r PasswordRecipe) length() int {
+	if r.Length == 0 {
+		return defaultPasswordLength
+	}
+	return r.Length
+}
+
+// classes returns the selected character classes without the excluded characters.
+func (r PasswordRecipe) classes() []string {
+	letters, digits, symbols := r.selectedClasses()
+	var classes []string
+	for _, class := range []struct {
+		selected   bool
+		characters string
+	}{{letters, passwordLetters}, {digits, passwordDigits}, {symbols, passwordSymbols}} {
+		if class.selected {
+			classes = append(classes, strings.Map(func(c rune) rune {
+				if strings.ContainsRune(r.ExcludeCharacters, c) {
+					return -1
+				}
+				return c
+			}, class.characters))
+		}
+	}
+	return classes
+}
+
+// opRecipe returns the recipe in `--generate-password=` syntax, e.g. letters,digits,32.
+// Recipes the op CLI cannot express - passphrases and excluded characters - are reported as not ok.
+func (r PasswordRecipe) opRecipe() (string, bool) {
+	if r.Words > 0 || r.ExcludeCharacters != "" {
+		return "", false
+	}
+	letters, digits, symbols := r.selectedClasses()
+	var parts []string
+	if letters {
+		parts = append(parts, "letters")
+	}
+	if digits {
+		parts = append(parts, "digits")
+	}
+	if symbols {
+		parts = append(parts, "symbols")
+	}
+	return strings.Join(append(parts, strconv.Itoa(r.length())), ","), true
+}
+
+// generate returns a new random password or passphrase made with crypto/rand.
+func (r PasswordRecipe) generate() (string, error) {
+	if err := r.validate(); err != nil {
+		return "", err
+	}
+	if r.Words > 0 {
+		return r.generatePassphrase()
+	}
+	classes := r.classes()
+	password := make([]byte, 0, r.length())
+	for _, class := range classes {
+		c, err := randomElement(class)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	all := strings.Join(classes, "")
+	for len(password) < r.length() {
+		c, err := randomElement(all)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	// shuffle, so the guaranteed characters of every class are not always in front
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randomIndex(i + 1)
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+	return string(password), nil
+}
+
+func (r PasswordRecipe) generatePassphrase() (string, error) {
+	words := make([]string, r.Words)
+	for i := range words {
+		j, err := randomIndex(len(r.WordList))
+		if err != nil {
+			return "", err
+		}
+		words[i] = r.WordList[j]
+	}
+	separator := r.Separator
+	if separator == "" {
+		separator = "-"
+	}
+	return strings.Join(words, separator), nil
+}
+
+func randomElement(characters string) (byte, error) {
+	i, err := randomIndex(len(characters))
+	if err != nil {
+		return 0, err
+	}
+	return characters[i], nil
+}
+
+func randomIndex(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(i.Int64()), nil
+}
+
+// generatePasswordArgs prepares the `op item create` arguments and template for the recipe of the template.
+// Recipes the op CLI can express are passed as `--generate-password=`, others are generated locally
+// into the PASSWORD field of the template, which is added when missing.
+func generatePasswordArgs(template ItemTemplate) (ItemTemplate, []string, error) {
+	if template.GeneratePassword == nil {
+		return template, nil, nil
+	}
+	recipe := *template.GeneratePassword
+	if err := recipe.validate(); err != nil {
+		return template, nil, err
+	}
+	if opRecipe, ok := recipe.opRecipe(); ok {
+		return template, []string{"--generate-password=" + opRecipe}, nil
+	}
+	password, err := recipe.generate()
+	if err != nil {
+		return template, nil, err
+	}
+	fields := make([]TemplateField, 0, len(template.Fields)+1)
+	found := false
+	for _, f := range template.Fields {
+		if f.Purpose == passwordPurpose {
+			f.Value = password
+			found = true
+		}
+		fields = append(fields, f)
+	}
+	if !found {
+		fields = append(fields, TemplateField{
+			ID: "password", Type: concealedFieldType, Purpose: passwordPurpose, Label: "password", Value: password,
+		})
+	}
+	template.Fields = fields
+	return template, nil, nil
+}
+
+// RotateField replaces the value of the concealed field referenced by uri with a password generated
+// from recipe and returns the new value. The item is read fresh from 1Password and edited through a
+// temporary template file, so the new value never shows up in process arguments, and the cache is
+// refreshed with the edited item.
+func (cli *OnePassword) RotateField(ctx context.Context, uri string, recipe PasswordRecipe) (string, error) {
+	if !strings.HasPrefix(uri, opURIPrefix) {
+		return "", &InvalidOpURIError{uri: uri}
+	}
+	opURI, err := cli.newOpURI(uri)
+	if err != nil {
+		return "", err
+	}
+	password, err := recipe.generate()
+	if err != nil {
+		return "", err
+	}
+	raw, err := cli.execute(ctx, "item", "get", opURI.item, "--vault", opURI.vault, "--format", "json")
+	if err != nil {
+		return "", err
+	}
+	template, err := replaceFieldValue(raw, opURI, password)
+	if err != nil {
+		return "", err
+	}
+	var edited Item
+	err = withTempFile(templateFileName, bytes.NewReader(template), func(path string) error {
+		return cli.executeJSON(ctx, &edited, "item", "edit", opURI.item, "--vault", opURI.vault, "--template", path)
+	})
+	if err != nil {
+		return "", err
+	}
+	cli.invalidateItem(opURI.vault, opURI.item)
+	cli.setVaultItem(opURI.vault, opURI.item, edited)
+	cli.logger.DebugContext(ctx, "rotated 1Password field", "vault", opURI.vault, "item", opURI.item,
+		"section", opURI.section, "field", opURI.field)
+	return password, nil
+}
+
+// replaceFieldValue sets the value of the field referenced by uri in the raw `op item get` JSON output.
+// Everything else is kept as is, so the edit does not drop parts of the item this package does not model.
+func replaceFieldValue(raw []byte, uri *OpURI, value string) ([]byte, error) {
+	var item map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return nil, err
+	}
+	var fields []json.RawMessage
+	if err := json.Unmarshal(item["fields"], &fields); err != nil {
+		return nil, err
+	}
+	for i, rawField := range fields {
+		var f ItemField
+		if err := json.Unmarshal(rawField, &f); err != nil {
+			return nil, err
+		}
+		if !f.matchField(uri) {
+			continue
+		}
+		if f.Type != concealedFieldType {
+			return nil, fmt.Errorf("field %s is not concealed and cannot be rotated", uri.field)
+		}
+		var field map[string]json.RawMessage
+		if err := json.Unmarshal(rawField, &field); err != nil {
+			return nil, err
+		}
+		var err error
+		if field["value"], err = json.Marshal(value); err != nil {
+			return nil, err
+		}
+		if fields[i], err = json.Marshal(field); err != nil {
+			return nil, err
+		}
+		if item["fields"], err = json.Marshal(fields); err != nil {
+			return nil, err
+		}
+		return json.Marshal(item)
+	}
+	return nil, &FieldNotFoundError{Section: uri.section, Field: uri.field}
+}
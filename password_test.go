@@ -0,0 +1,188 @@
+package gonepassword
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPasswordRecipeOpRecipe(t *testing.T) {
+	testCases := []struct {
+		recipe   PasswordRecipe
+		expected string
+		ok       bool
+	}{
+		{recipe: PasswordRecipe{}, expected: "letters,digits,symbols,32", ok: true},
+		{recipe: PasswordRecipe{Length: 20, Letters: true, Digits: true}, expected: "letters,digits,20", ok: true},
+		{recipe: PasswordRecipe{Length: 6, Digits: true}, expected: "digits,6", ok: true},
+		{recipe: PasswordRecipe{ExcludeCharacters: "'\"\\"}, ok: false},
+		{recipe: PasswordRecipe{Words: 4, WordList: []string{"correct", "horse"}}, ok: false},
+	}
+
+	for _, tc := range testCases {
+		actual, ok := tc.recipe.opRecipe()
+		assert.Equal(t, tc.ok, ok, "recipe %+v", tc.recipe)
+		assert.Equal(t, tc.expected, actual, "recipe %+v", tc.recipe)
+	}
+}
+
+func TestPasswordRecipeGenerate(t *testing.T) {
+	recipe := PasswordRecipe{Length: 12, Letters: true, Digits: true, ExcludeCharacters: "0O1lI"}
+	for i := 0; i < 100; i++ {
+		password, err := recipe.generate()
+		assert.NoError(t, err)
+		assert.Len(t, password, 12)
+		assert.False(t, strings.ContainsAny(password, "0O1lI"+passwordSymbols), "unexpected character in %s", password)
+		assert.True(t, strings.ContainsAny(password, passwordDigits), "no digit in %s", password)
+		assert.True(t, strings.ContainsAny(password, passwordLetters), "no letter in %s", password)
+	}
+
+	password, err := PasswordRecipe{}.generate()
+	assert.NoError(t, err)
+	assert.Len(t, password, 32)
+
+	passphrase, err := PasswordRecipe{Words: 3, WordList: []string{"horse"}, Separator: " "}.generate()
+	assert.NoError(t, err)
+	assert.Equal(t, "horse horse horse", passphrase)
+}
+
+func TestPasswordRecipeValidation(t *testing.T) {
+	testCases := []struct {
+		recipe        PasswordRecipe
+		expectedError string
+	}{
+		{
+			recipe:        PasswordRecipe{Length: 65},
+			expectedError: "invalid password recipe - length must be between 1 and 64 - got 65",
+		},
+		{
+			recipe:        PasswordRecipe{Length: 2},
+			expectedError: "invalid password recipe - length 2 is too short to use 3 character classes",
+		},
+		{
+			recipe:        PasswordRecipe{Digits: true, ExcludeCharacters: passwordDigits},
+			expectedError: "invalid password recipe - excluded characters leave a character class empty",
+		},
+		{
+			recipe:        PasswordRecipe{Words: 4},
+			expectedError: "invalid password recipe - a passphrase needs a word list",
+		},
+	}
+
+	for _, tc := range testCases {
+		_, err := tc.recipe.generate()
+		assert.EqualError(t, err, tc.expectedError)
+	}
+}
+
+func TestCreateItemGeneratesPassword(t *testing.T) {
+	t.Run("should pass recipes op understands to the op CLI", func(t *testing.T) {
+		executor := &TempFileCommandExecutor{Output: []byte(`{"id": "abc123"}`)}
+		cli, err := New1Password(executor, OnePasswordOptions{})
+		assert.NoError(t, err)
+
+		_, err = cli.CreateItem(context.Background(), "vault", ItemTemplate{
+			Title: "db", Category: "LOGIN", GeneratePassword: &PasswordRecipe{Length: 20, Letters: true, Digits: true},
+		})
+
+		assert.NoError(t, err)
+		assert.Contains(t, executor.Calls[0], "--generate-password=letters,digits,20")
+		assert.JSONEq(t, `{"title": "db", "category": "LOGIN"}`, string(executor.Content))
+	})
+
+	t.Run("should generate other recipes into the password field", func(t *testing.T) {
+		executor := &TempFileCommandExecutor{Output: []byte(`{"id": "abc123"}`)}
+		cli, err := New1Password(executor, OnePasswordOptions{})
+		assert.NoError(t, err)
+
+		_, err = cli.CreateItem(context.Background(), "vault", ItemTemplate{
+			Title: "db", Category: "LOGIN", GeneratePassword: &PasswordRecipe{Length: 16, ExcludeCharacters: "'\""},
+		})
+
+		assert.NoError(t, err)
+		for _, arg := range executor.Calls[0] {
+			assert.False(t, strings.HasPrefix(arg, "--generate-password"))
+		}
+		var template ItemTemplate
+		assert.NoError(t, json.Unmarshal(executor.Content, &template))
+		assert.Len(t, template.Fields, 1)
+		assert.Equal(t, passwordPurpose, template.Fields[0].Purpose)
+		assert.Len(t, template.Fields[0].Value, 16)
+	})
+}
+
+// RotateCommandExecutor answers `op item get` with Item and `op item edit` with the item from the template,
+// the way the op CLI prints an edited item.
+type RotateCommandExecutor struct {
+	Item  string
+	Calls [][]string
+}
+
+func (e *RotateCommandExecutor) IsInstalled() bool {
+	return true
+}
+
+func (e *RotateCommandExecutor) Execute(_ context.Context, arg ...string) ([]byte, error) {
+	e.Calls = append(e.Calls, arg)
+	if arg[1] == "edit" {
+		return os.ReadFile(argValue(arg, "--template"))
+	}
+	return []byte(e.Item), nil
+}
+
+func TestRotateField(t *testing.T) {
+	executor := &RotateCommandExecutor{Item: `{"id": "abc123", "title": "db", "urls": [{"href": "https://db"}],
+		"fields": [
+			{"id": "username", "type": "STRING", "label": "username", "value": "admin"},
+			{"id": "password", "type": "CONCEALED", "purpose": "PASSWORD", "label": "password", "value": "old",
+			 "entropy": 115.40191650390625}
+		]}`}
+	cli, err := New1Password(executor, OnePasswordOptions{})
+	assert.NoError(t, err)
+	cli.setVaultItem("vault", "db", Item{ID: "abc123", Title: "db", Fields: []ItemField{{ID: "password", Value: "old"}}})
+
+	password, err := cli.RotateField(context.Background(), "op://vault/db/password", PasswordRecipe{Length: 24})
+
+	assert.NoError(t, err)
+	assert.Len(t, password, 24)
+	assert.Equal(t, []string{"item", "get", "db", "--vault", "vault", "--format", "json"}, executor.Calls[0])
+	assert.Equal(t, []string{"item", "edit", "db", "--vault", "vault", "--template"}, executor.Calls[1][:6])
+	value, err := cli.ResolveOpURI("op://vault/db/password")
+	assert.NoError(t, err)
+	assert.Equal(t, password, value)
+	value, err = cli.ResolveOpURI("op://vault/db/username")
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", value)
+	assert.Len(t, executor.Calls, 2)
+}
+
+func TestRotateFieldRefusesFieldsWhichAreNotConcealed(t *testing.T) {
+	executor := &RotateCommandExecutor{Item: `{"id": "abc123", "fields": [
+		{"id": "username", "type": "STRING", "label": "username", "value": "admin"}
+	]}`}
+	cli, err := New1Password(executor, OnePasswordOptions{})
+	assert.NoError(t, err)
+
+	_, err = cli.RotateField(context.Background(), "op://vault/db/username", PasswordRecipe{})
+	assert.EqualError(t, err, "field username is not concealed and cannot be rotated")
+	_, err = cli.RotateField(context.Background(), "op://vault/db/missing", PasswordRecipe{})
+	assert.EqualError(t, err, "field missing not found")
+	assert.Len(t, executor.Calls, 2)
+}
+
+func TestReplaceFieldValueKeepsUnknownParts(t *testing.T) {
+	opURI, err := NewOpURI("op://vault/db/password")
+	assert.NoError(t, err)
+
+	template, err := replaceFieldValue([]byte(`{"id": "abc123", "unknown": {"nested": [1, 2]},
+		"fields": [{"id": "password", "type": "CONCEALED", "value": "old", "entropy": 115.40191650390625}]}`),
+		opURI, "new")
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id": "abc123", "unknown": {"nested": [1, 2]},
+		"fields": [{"id": "password", "type": "CONCEALED", "value": "new", "entropy": 115.40191650390625}]}`,
+		string(template))
+}
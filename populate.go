@@ -0,0 +1,245 @@
+package gonepassword
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect" //nolint:depguard // walking arbitrary config structs is not possible without reflection
+	"strconv"
+	"time"
+)
+
+const populateTag = "op"
+
+var (
+	secretType   = reflect.TypeOf(Secret{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// populateTarget is a single tagged struct field waiting for its resolved value.
+type populateTarget struct {
+	path  string
+	uri   string
+	value reflect.Value
+}
+
+// populatePointer identifies a pointer already walked, so cyclic values are walked only once.
+type populatePointer struct {
+	t       reflect.Type
+	address uintptr
+}
+
+// populateWalker collects the tagged fields of a struct.
+type populateWalker struct {
+	targets []populateTarget
+	// enclosing holds the struct types on the path to the walked value, nil pointers to them are not allocated,
+	// otherwise self-referential types would be allocated forever.
+	enclosing map[reflect.Type]bool
+	visited   map[populatePointer]bool
+}
+
+// Populate fills fields of the struct pointed to by target with values of their `op:"op://vault/item/field"` tags.
+// Tagged fields can be strings, []byte, Secret, booleans, integers, floats, time.Duration or pointers to those.
+// Nested structs, pointers to structs and slices or arrays of them are walked as well, nil pointers leading to
+// tagged fields get allocated unless they point to a struct type enclosing them, like the next node of a linked list.
+// All URIs are resolved with ResolveMany, so each item is fetched only once.
+func (cli *OnePassword) Populate(ctx context.Context, target any) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("populate target must be a non-nil pointer to a struct, got %T", target)
+	}
+	walker := populateWalker{enclosing: map[reflect.Type]bool{}, visited: map[populatePointer]bool{}}
+	if err := walker.collectTargets(value.Elem(), value.Elem().Type().String()); err != nil {
+		return err
+	}
+	targets := walker.targets
+	if len(targets) == 0 {
+		return nil
+	}
+	uris := make([]string, 0, len(targets))
+	for _, t := range targets {
+		uris = append(uris, t.uri)
+	}
+	values, err := cli.ResolveMany(ctx, uris)
+	if err != nil {
+		return err
+	}
+	for _, t := range targets {
+		if err := setPopulateValue(t.value, values[t.uri]); err != nil {
+			return fmt.Errorf("cannot populate %s from %s: %w", t.path, t.uri, err)
+		}
+	}
+	return nil
+}
+
+// collectTargets walks the given struct value and collects its tagged fields.
+func (w *populateWalker) collectTargets(value reflect.Value, path string) error {
+	valueType := value.Type()
+	if !w.enclosing[valueType] {
+		w.enclosing[valueType] = true
+		defer delete(w.enclosing, valueType)
+	}
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldPath := path + "." + field.Name
+		fieldValue := value.Field(i)
+		if uri, ok := field.Tag.Lookup(populateTag); ok {
+			leaf, err := populateLeaf(fieldValue, fieldPath)
+			if err != nil {
+				return err
+			}
+			w.targets = append(w.targets, populateTarget{path: fieldPath, uri: uri, value: leaf})
+			continue
+		}
+		if err := w.collectNested(fieldValue, fieldPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectNested walks untagged structs, pointers, slices and arrays looking for tagged fields.
+func (w *populateWalker) collectNested(value reflect.Value, path string) error {
+	switch value.Kind() {
+	case reflect.Struct:
+		return w.collectTargets(value, path)
+	case reflect.Pointer:
+		if value.IsNil() {
+			elem := value.Type().Elem()
+			if w.enclosing[elem] || !hasPopulateTags(elem, map[reflect.Type]bool{}) {
+				return nil
+			}
+			value.Set(reflect.New(elem))
+		}
+		pointer := populatePointer{t: value.Type(), address: value.Pointer()}
+		if w.visited[pointer] {
+			return nil
+		}
+		w.visited[pointer] = true
+		return w.collectNested(value.Elem(), path)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := w.collectNested(value.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	default:
+	}
+	return nil
+}
+
+// hasPopulateTags reports whether values of the given type can hold tagged fields.
+func hasPopulateTags(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[t] {
+		return false
+	}
+	visited[t] = true
+	switch t.Kind() {
+	case reflect.Pointer:
+		return hasPopulateTags(t.Elem(), visited)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if _, ok := field.Tag.Lookup(populateTag); ok || hasPopulateTags(field.Type, visited) {
+				return true
+			}
+		}
+	default:
+	}
+	return false
+}
+
+// populateLeaf returns the settable value behind a tagged field, allocating nil pointers.
+func populateLeaf(value reflect.Value, path string) (reflect.Value, error) {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		value = value.Elem()
+	}
+	if !isPopulateKind(value.Type()) {
+		return reflect.Value{}, fmt.Errorf("cannot populate %s - unsupported type %s", path, value.Type())
+	}
+	return value, nil
+}
+
+func isPopulateKind(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	case reflect.Struct:
+		return t == secretType
+	default:
+		return false
+	}
+}
+
+// setPopulateValue converts the resolved value to the type of the target and sets it.
+// Parsing errors never include the value itself, as it is a secret.
+func setPopulateValue(target reflect.Value, value string) error {
+	switch {
+	case target.Type() == secretType:
+		target.Set(reflect.ValueOf(NewSecret(value)))
+		return nil
+	case target.Type() == durationType:
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.New("invalid duration")
+		}
+		target.SetInt(int64(duration))
+		return nil
+	}
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(value)
+	case reflect.Slice:
+		target.SetBytes([]byte(value))
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("invalid boolean")
+		}
+		target.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, target.Type().Bits())
+		if err != nil {
+			return numError(err)
+		}
+		target.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, target.Type().Bits())
+		if err != nil {
+			return numError(err)
+		}
+		target.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, target.Type().Bits())
+		if err != nil {
+			return numError(err)
+		}
+		target.SetFloat(parsed)
+	default:
+		return fmt.Errorf("unsupported type %s", target.Type())
+	}
+	return nil
+}
+
+// numError strips the parsed input from strconv errors, leaving only the reason.
+func numError(err error) error {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		return fmt.Errorf("invalid number: %w", numErr.Err)
+	}
+	return err
+}
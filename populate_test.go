@@ -0,0 +1,119 @@
+package gonepassword
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type populateDatabase struct {
+	Host     string  `op:"op://vault/db/host"`
+	Port     int     `op:"op://vault/db/port"`
+	User     *string `op:"op://vault/db/username"`
+	Password Secret  `op:"op://vault/db/password"`
+	Name     string
+}
+
+type populateWorker struct {
+	Token   []byte        `op:"op://vault/api/token"`
+	Timeout time.Duration `op:"op://vault/api/timeout"`
+}
+
+type populateConfig struct {
+	Database  populateDatabase
+	Replica   *populateDatabase
+	Workers   []populateWorker
+	Ratio     float64 `op:"op://vault/api/ratio"`
+	Enabled   bool    `op:"op://vault/api/enabled"`
+	Untouched *populateWorker
+	Plain     *struct{ Name string }
+	ignored   string `op:"op://vault/api/token"` //nolint:unused
+}
+
+type populateUnsupported struct {
+	Tags []string `op:"op://vault/api/token"`
+}
+
+type populateInvalidNumber struct {
+	Port int `op:"op://vault/api/not-a-number"`
+}
+
+type populateNode struct {
+	Token string `op:"op://vault/api/token"`
+	Next  *populateNode
+}
+
+func TestPopulate(t *testing.T) {
+	executor := itemsExecutor()
+	cli, err := New1Password(executor, OnePasswordOptions{})
+	assert.NoError(t, err)
+	config := populateConfig{
+		Database: populateDatabase{Name: "app"},
+		Workers:  make([]populateWorker, 2),
+	}
+
+	err = cli.Populate(context.Background(), &config)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", config.Database.Host)
+	assert.Equal(t, 5432, config.Database.Port)
+	assert.Equal(t, "admin", *config.Database.User)
+	assert.Equal(t, "hunter2", config.Database.Password.Reveal())
+	assert.Equal(t, "app", config.Database.Name)
+	assert.NotNil(t, config.Replica)
+	assert.Equal(t, "localhost", config.Replica.Host)
+	for _, worker := range config.Workers {
+		assert.Equal(t, []byte("t0ken"), worker.Token)
+		assert.Equal(t, 90*time.Second, worker.Timeout)
+	}
+	assert.Equal(t, 0.25, config.Ratio)
+	assert.True(t, config.Enabled)
+	assert.NotNil(t, config.Untouched)
+	assert.Nil(t, config.Plain)
+	assert.Equal(t, "", config.ignored)
+	assert.Equal(t, map[string]int{"db": 1, "api": 1}, executor.Calls)
+}
+
+func TestPopulateErrors(t *testing.T) {
+	cli, err := New1Password(itemsExecutor(), OnePasswordOptions{})
+	assert.NoError(t, err)
+
+	var config populateConfig
+	err = cli.Populate(context.Background(), config)
+	assert.EqualError(t, err, "populate target must be a non-nil pointer to a struct, got gonepassword.populateConfig")
+
+	err = cli.Populate(context.Background(), &populateUnsupported{})
+	assert.EqualError(t, err, "cannot populate gonepassword.populateUnsupported.Tags - unsupported type []string")
+
+	err = cli.Populate(context.Background(), &populateInvalidNumber{})
+	assert.EqualError(t, err, "cannot populate gonepassword.populateInvalidNumber.Port "+
+		"from op://vault/api/not-a-number: invalid number: invalid syntax")
+	assert.NotContains(t, err.Error(), "hunter2")
+
+	var missing struct {
+		Value string `op:"op://vault/api/missing"`
+	}
+	err = cli.Populate(context.Background(), &missing)
+	var resolveManyError *ResolveManyError
+	assert.True(t, errors.As(err, &resolveManyError))
+}
+
+func TestPopulateSelfReferentialTypes(t *testing.T) {
+	cli, err := New1Password(itemsExecutor(), OnePasswordOptions{})
+	assert.NoError(t, err)
+
+	node := populateNode{Next: &populateNode{}}
+	err = cli.Populate(context.Background(), &node)
+	assert.NoError(t, err)
+	assert.Equal(t, "t0ken", node.Token)
+	assert.Equal(t, "t0ken", node.Next.Token)
+	assert.Nil(t, node.Next.Next)
+
+	cyclic := populateNode{}
+	cyclic.Next = &cyclic
+	err = cli.Populate(context.Background(), &cyclic)
+	assert.NoError(t, err)
+	assert.Equal(t, "t0ken", cyclic.Token)
+}
@@ -1,44 +1,186 @@
 package gonepassword
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"os"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"strings"
 	"time"
 )
 
 type nonRetryableError struct {
 	Message string
+	Err     error
 }
 
 func (e nonRetryableError) Error() string {
 	return e.Message
 }
 
-type retryAbleFunc func() (any, error)
-type backOffFunc func(attempt int) time.Duration
+func (e nonRetryableError) Unwrap() error {
+	return e.Err
+}
+
+// RetryPolicy controls how failed op CLI calls are retried.
+// The zero value stands for DefaultRetryPolicy, zero Attempts, BaseDelay, MaxDelay and Retryable of
+// any other policy are taken from DefaultRetryPolicy.
+type RetryPolicy struct {
+	// Attempts is the maximum number of calls including the first one, negative values mean a single call.
+	Attempts int
+	// BaseDelay is the delay before the first retry, it doubles with every next retry.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between two calls.
+	MaxDelay time.Duration
+	// Jitter randomizes every delay by up to the given fraction of it, e.g. 0.2 means ±20%.
+	Jitter float64
+	// MaxElapsed bounds the time spent on all calls and delays together, zero means no limit.
+	MaxElapsed time.Duration
+	// Retryable decides whether a failed call is worth retrying, DefaultRetryable is used when nil.
+	Retryable func(err error) bool
+}
+
+// DefaultRetryPolicy returns the policy used when OnePasswordOptions.RetryPolicy is left empty.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Attempts:  5,
+		BaseDelay: 2 * time.Second,
+		MaxDelay:  30 * time.Second,
+		Jitter:    0.2,
+		Retryable: DefaultRetryable,
+	}
+}
+
+func (p RetryPolicy) isZero() bool {
+	return p.Attempts == 0 && p.BaseDelay == 0 && p.MaxDelay == 0 && p.Jitter == 0 && p.MaxElapsed == 0 &&
+		p.Retryable == nil
+}
+
+// withDefaults replaces the zero policy with DefaultRetryPolicy and fills in its other zero fields,
+// so a policy only setting Attempts does not retry without any delay.
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	defaults := DefaultRetryPolicy()
+	if p.isZero() {
+		return defaults
+	}
+	if p.Attempts == 0 {
+		p.Attempts = defaults.Attempts
+	}
+	if p.BaseDelay == 0 {
+		p.BaseDelay = defaults.BaseDelay
+	}
+	if p.MaxDelay == 0 {
+		p.MaxDelay = defaults.MaxDelay
+	}
+	if p.Retryable == nil {
+		p.Retryable = defaults.Retryable
+	}
+	return p
+}
+
+// delay returns how long to wait after the given zero-based failed attempt.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	delay := p.BaseDelay << min(attempt, 30)
+	if delay < 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		//nolint:gosec // jitter does not need a cryptographically secure source
+		delay = time.Duration(float64(delay) * (1 + p.Jitter*(2*rand.Float64()-1)))
+	}
+	if p.MaxDelay > 0 {
+		delay = min(delay, p.MaxDelay)
+	}
+	return max(delay, 0)
+}
+
+var networkErrorMarkers = []string{
+	"https://", "timeout", "timed out", "connection reset", "connection refused", "no such host",
+	"network is unreachable", "tls handshake", "unexpected eof", "temporary failure",
+	"internal server error", "bad gateway", "service unavailable", "gateway timeout",
+}
+
+// DefaultRetryable retries rate limiting, network and timeout errors. Errors that would fail the same way again,
+// like a missing vault, item or field, an ambiguous item, a missing sign in or denied authorization, are never
+// retried, neither is a cancelled context.
+func DefaultRetryable(err error) bool {
+	var rateLimitedError *RateLimitedError
+	var netError net.Error
+	switch {
+	case err == nil,
+		errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded),
+		isPermanentError(err):
+		return false
+	case errors.As(err, &rateLimitedError):
+		return true
+	case errors.As(err, &netError):
+		return true
+	}
+	return containsAny(strings.ToLower(err.Error()), networkErrorMarkers)
+}
 
-func exponentialBackoff(attempt int) time.Duration {
-	return time.Duration(1<<(attempt+1)) * time.Second
+func isPermanentError(err error) bool {
+	var vaultNotFoundError *VaultNotFoundError
+	var itemNotFoundError *ItemNotFoundError
+	var fieldNotFoundError *FieldNotFoundError
+	var ambiguousItemError *AmbiguousItemError
+	var notSignedInError *NotSignedInError
+	var authorizationDeniedError *AuthorizationDeniedError
+	var nonRetryableError *nonRetryableError
+	return errors.As(err, &vaultNotFoundError) || errors.As(err, &itemNotFoundError) ||
+		errors.As(err, &fieldNotFoundError) || errors.As(err, &ambiguousItemError) ||
+		errors.As(err, &notSignedInError) || errors.As(err, &authorizationDeniedError) ||
+		errors.As(err, &nonRetryableError)
 }
 
-func retry(retries int, backoff backOffFunc, f retryAbleFunc) (any, error) {
+type retryAbleFunc func(ctx context.Context) (any, error)
+
+// retry calls f until it succeeds, returns a non-retryable error, the policy classifies its error as not retryable
+// or runs out of attempts.
+// It gives up early with ctx.Err() wrapping the last error once ctx is done or policy.MaxElapsed passes,
+// including while waiting for the next attempt.
+func retry(ctx context.Context, policy RetryPolicy, logger *slog.Logger, f retryAbleFunc) (any, error) {
+	policy = policy.withDefaults()
+	if policy.MaxElapsed > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.MaxElapsed)
+		defer cancel()
+	}
+	attempts := max(policy.Attempts, 1)
 	var output any
 	var err error
 	var nonRetryableError *nonRetryableError
-	for i := 0; i < retries; i++ {
-		output, err = f()
+	for i := 0; i < attempts; i++ {
+		output, err = f(ctx)
 		if err == nil {
 			break
 		}
-		if errors.As(err, &nonRetryableError) {
+		if ctx.Err() != nil {
+			return output, fmt.Errorf("%w: %w", ctx.Err(), err)
+		}
+		if i == attempts-1 || errors.As(err, &nonRetryableError) || !policy.Retryable(err) {
 			break
 		}
-		if i <= retries {
-			backoffTime := backoff(i)
-			fmt.Fprintf(os.Stderr, "retrying in %.0f seconds...\n", backoffTime.Seconds())
-			time.Sleep(backoffTime)
+		backoffTime := policy.delay(i)
+		logger.WarnContext(ctx, "retrying op command", "attempt", i+1, "delay", backoffTime, "error", err)
+		if sleepErr := sleep(ctx, backoffTime); sleepErr != nil {
+			return output, fmt.Errorf("%w: %w", sleepErr, err)
 		}
 	}
 	return output, err
 }
+
+// sleep waits for the given duration or until ctx is done, whichever comes first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -2,53 +2,55 @@ package gonepassword
 
 import (
 	"bytes"
+	"context"
 	"errors"
-	"io"
-	"os"
-	"strings"
+	"log/slog"
+	"net"
 	"testing"
 	"time"
 )
 
-func MilliExponentialBackoff(attempt int) time.Duration {
-	// that will print 0 seconds in output but hey - it will be fast for tests
-	return time.Duration(1<<(attempt+1)) * time.Millisecond
+// milliRetryPolicy retries every error with millisecond delays
+// that will print 0 seconds in output but hey - it will be fast for tests.
+func milliRetryPolicy(attempts int) RetryPolicy {
+	return RetryPolicy{
+		Attempts:  attempts,
+		BaseDelay: 2 * time.Millisecond,
+		Retryable: func(error) bool { return true },
+	}
 }
 
 func TestRetry(t *testing.T) { //nolint
 	recoveredRetry := 0
 	testCases := []struct {
 		name           string
-		retries        int
-		backoff        backOffFunc
+		policy         RetryPolicy
 		f              retryAbleFunc
 		expectedOutput any
 		expectedError  string
-		expectedStderr string
+		expectedLogs   string
 	}{
 		{
 			name:           "should return output if no error",
-			retries:        3,
-			backoff:        MilliExponentialBackoff,
-			f:              func() (any, error) { return "output", nil },
+			policy:         milliRetryPolicy(3),
+			f:              func(context.Context) (any, error) { return "output", nil },
 			expectedOutput: "output",
 			expectedError:  "",
-			expectedStderr: "",
+			expectedLogs:   "",
 		},
 		{
 			name:           "should retry on error",
-			retries:        3,
-			backoff:        MilliExponentialBackoff,
-			f:              func() (any, error) { return nil, errors.New("error") },
+			policy:         milliRetryPolicy(3),
+			f:              func(context.Context) (any, error) { return nil, errors.New("error") },
 			expectedOutput: nil,
 			expectedError:  "error",
-			expectedStderr: "retrying in 0 seconds...\nretrying in 0 seconds...\nretrying in 0 seconds...\n",
+			expectedLogs: "level=WARN msg=\"retrying op command\" attempt=1 delay=2ms error=error\n" +
+				"level=WARN msg=\"retrying op command\" attempt=2 delay=4ms error=error\n",
 		},
 		{
-			name:    "should return output on successful retry",
-			retries: 3,
-			backoff: MilliExponentialBackoff,
-			f: func() (any, error) {
+			name:   "should return output on successful retry",
+			policy: milliRetryPolicy(3),
+			f: func(context.Context) (any, error) {
 				recoveredRetry++
 				if recoveredRetry > 2 {
 					return "success", nil
@@ -57,25 +59,25 @@ func TestRetry(t *testing.T) { //nolint
 			},
 			expectedOutput: "success",
 			expectedError:  "",
-			expectedStderr: "retrying in 0 seconds...\nretrying in 0 seconds...\n",
+			expectedLogs: "level=WARN msg=\"retrying op command\" attempt=1 delay=2ms error=error\n" +
+				"level=WARN msg=\"retrying op command\" attempt=2 delay=4ms error=error\n",
 		},
 		{
-			name:    "should return output if error is non-retryable",
-			retries: 3,
-			backoff: MilliExponentialBackoff,
-			f: func() (any, error) {
+			name:   "should return output if error is non-retryable",
+			policy: milliRetryPolicy(3),
+			f: func(context.Context) (any, error) {
 				return "output", &nonRetryableError{Message: "non-retryable error"}
 			},
 			expectedOutput: "output",
 			expectedError:  "non-retryable error",
-			expectedStderr: "",
+			expectedLogs:   "",
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			capturedStderr, output, err := captureStderrAndCallFunc(func() (any, error) {
-				return retry(tc.retries, tc.backoff, tc.f)
+			capturedLogs, output, err := captureLogsAndCallFunc(func(logger *slog.Logger) (any, error) {
+				return retry(context.Background(), tc.policy, logger, tc.f)
 			})
 
 			if tc.expectedError != "" && (err == nil || err.Error() != tc.expectedError) {
@@ -86,33 +88,200 @@ func TestRetry(t *testing.T) { //nolint
 				t.Errorf("Expected output %v, got %v", tc.expectedOutput, output)
 			}
 
-			if tc.expectedStderr != "" && !strings.Contains(capturedStderr, tc.expectedStderr) {
-				t.Errorf("Expected stderr to contain %q, got %q", tc.expectedStderr, capturedStderr)
+			if tc.expectedLogs != capturedLogs {
+				t.Errorf("Expected logs %q, got %q", tc.expectedLogs, capturedLogs)
 			}
 		})
 	}
 }
 
-func captureStderrAndCallFunc(f func() (any, error)) (capturedStderr string, output any, err error) {
-	originalStderr := os.Stderr
-	r, w, _ := os.Pipe()
-	os.Stderr = w
+func TestRetryStopsWhenContextIsDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	hourBackoff := RetryPolicy{Attempts: 3, BaseDelay: time.Hour, Retryable: func(error) bool { return true }}
 
-	outC := make(chan string)
 	go func() {
-		var buf bytes.Buffer
-		_, err := io.Copy(&buf, r)
-		if err != nil {
-			return
-		}
-		outC <- buf.String()
+		time.Sleep(10 * time.Millisecond)
+		cancel()
 	}()
+	_, _, err := captureLogsAndCallFunc(func(logger *slog.Logger) (any, error) {
+		return retry(ctx, hourBackoff, logger, func(context.Context) (any, error) {
+			attempts++
+			return nil, errors.New("error")
+		})
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestRetryHonoursPolicy(t *testing.T) {
+	testCases := []struct {
+		name             string
+		policy           RetryPolicy
+		err              error
+		expectedAttempts int
+		expectedError    error
+	}{
+		{
+			name:             "should not retry errors the classifier rejects",
+			policy:           RetryPolicy{Attempts: 5, Retryable: func(error) bool { return false }},
+			err:              errors.New("error"),
+			expectedAttempts: 1,
+		},
+		{
+			name:             "should use the default classifier when none is given",
+			policy:           RetryPolicy{Attempts: 5},
+			err:              &ItemNotFoundError{Vault: "vault", Item: "item"},
+			expectedAttempts: 1,
+		},
+		{
+			name:             "should retry with the default classifier",
+			policy:           RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond},
+			err:              &RateLimitedError{Message: "Too many requests"},
+			expectedAttempts: 3,
+		},
+		{
+			name:             "should make a single call when attempts are not positive",
+			policy:           RetryPolicy{Attempts: -1, Retryable: func(error) bool { return true }},
+			err:              errors.New("error"),
+			expectedAttempts: 1,
+		},
+		{
+			name: "should give up once max elapsed passes",
+			policy: RetryPolicy{
+				Attempts:   5,
+				BaseDelay:  time.Hour,
+				MaxElapsed: 20 * time.Millisecond,
+				Retryable:  func(error) bool { return true },
+			},
+			err:              errors.New("error"),
+			expectedAttempts: 1,
+			expectedError:    context.DeadlineExceeded,
+		},
+		{
+			name: "should keep the last error once max elapsed passes",
+			policy: RetryPolicy{
+				Attempts:   5,
+				BaseDelay:  time.Hour,
+				MaxElapsed: 20 * time.Millisecond,
+				Retryable:  func(error) bool { return true },
+			},
+			err:              &RateLimitedError{Message: "Too many requests"},
+			expectedAttempts: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			_, _, err := captureLogsAndCallFunc(func(logger *slog.Logger) (any, error) {
+				return retry(context.Background(), tc.policy, logger, func(context.Context) (any, error) {
+					attempts++
+					return nil, tc.err
+				})
+			})
+
+			expectedError := tc.expectedError
+			if expectedError == nil {
+				expectedError = tc.err
+			}
+			if !errors.Is(err, expectedError) {
+				t.Errorf("Expected error %v, got %v", expectedError, err)
+			}
+			if attempts != tc.expectedAttempts {
+				t.Errorf("Expected %d attempts, got %d", tc.expectedAttempts, attempts)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyWithDefaults(t *testing.T) {
+	defaults := DefaultRetryPolicy()
+
+	policy := RetryPolicy{Attempts: 3}.withDefaults()
+	if policy.Attempts != 3 || policy.BaseDelay != defaults.BaseDelay || policy.MaxDelay != defaults.MaxDelay ||
+		policy.Jitter != 0 || policy.Retryable == nil {
+		t.Errorf("Expected zero fields to be taken from the default policy, got %+v", policy)
+	}
 
-	output, err = f()
+	policy = RetryPolicy{BaseDelay: time.Millisecond, MaxElapsed: time.Minute}.withDefaults()
+	if policy.Attempts != defaults.Attempts || policy.BaseDelay != time.Millisecond || policy.MaxElapsed != time.Minute {
+		t.Errorf("Expected only zero fields to be replaced, got %+v", policy)
+	}
+
+	policy = RetryPolicy{}.withDefaults()
+	if policy.Jitter != defaults.Jitter || policy.Attempts != defaults.Attempts {
+		t.Errorf("Expected the zero policy to stand for the default policy, got %+v", policy)
+	}
+}
 
-	w.Close() //nolint
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 2 * time.Second, MaxDelay: 10 * time.Second}
+	expected := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for attempt, delay := range expected {
+		if actual := policy.delay(attempt); actual != delay {
+			t.Errorf("Expected delay %s for attempt %d, got %s", delay, attempt, actual)
+		}
+	}
+	if actual := policy.delay(100); actual != 10*time.Second {
+		t.Errorf("Expected delay to be capped on overflow, got %s", actual)
+	}
 
-	os.Stderr = originalStderr
-	capturedStderr = <-outC
-	return capturedStderr, output, err
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if actual := policy.delay(0); actual < time.Second || actual > 3*time.Second {
+			t.Errorf("Expected jittered delay within 1s..3s, got %s", actual)
+		}
+		if actual := policy.delay(10); actual < 5*time.Second || actual > 10*time.Second {
+			t.Errorf("Expected jittered delay within 5s..10s, got %s", actual)
+		}
+	}
+}
+
+func TestDefaultRetryable(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected bool
+	}{
+		{err: &RateLimitedError{Message: "Too many requests"}, expected: true},
+		{err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, expected: true},
+		{err: errors.New(`Get "https://my.1password.com/api": EOF`), expected: true},
+		{err: errors.New("request timed out"), expected: true},
+		{err: &VaultNotFoundError{Vault: "vault"}, expected: false},
+		{err: &ItemNotFoundError{Item: "item"}, expected: false},
+		{err: &FieldNotFoundError{Field: "field"}, expected: false},
+		{err: &AmbiguousItemError{Item: "item"}, expected: false},
+		{err: &NotSignedInError{Message: "https://support.1password.com"}, expected: false},
+		{err: &AuthorizationDeniedError{Message: "authorization prompt dismissed"}, expected: false},
+		{err: &nonRetryableError{Message: "connection reset"}, expected: false},
+		{err: context.Canceled, expected: false},
+		{err: errors.New("something went wrong"), expected: false},
+		{err: nil, expected: false},
+	}
+
+	for _, tc := range testCases {
+		if actual := DefaultRetryable(tc.err); actual != tc.expected {
+			t.Errorf("Expected DefaultRetryable(%v) to be %t", tc.err, tc.expected)
+		}
+	}
+}
+
+// captureLogsAndCallFunc calls f with a logger and returns everything it logged, without timestamps.
+func captureLogsAndCallFunc(f func(logger *slog.Logger) (any, error)) (capturedLogs string, output any, err error) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	output, err = f(logger)
+	return buf.String(), output, err
 }
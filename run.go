@@ -0,0 +1,187 @@
+package gonepassword
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// concealedSecret replaces secrets in masked output, the same placeholder `op run` uses.
+const concealedSecret = "<concealed by 1Password>"
+
+// RunOptions controls how RunWithSecrets runs the command.
+type RunOptions struct {
+	// MaskSecrets replaces every resolved secret written by the command to its stdout or stderr
+	// with <concealed by 1Password>.
+	MaskSecrets bool
+}
+
+// RunWithSecrets resolves every environment variable of cmd whose value is an op:// URI, then runs cmd and waits
+// for it to finish, like `op run` does. When cmd.Env is nil the current process environment is used.
+// The URIs are taken literally, variable references in them are not expanded.
+// Every item is fetched only once, nothing is started when any of the variables cannot be resolved.
+// Cancelling ctx kills the command.
+func (cli *OnePassword) RunWithSecrets(ctx context.Context, cmd *exec.Cmd, options RunOptions) error {
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	env = append([]string(nil), env...)
+	var indexes []int
+	var uris []string
+	for i, entry := range env {
+		if _, value, ok := strings.Cut(entry, "="); ok && strings.HasPrefix(value, opURIPrefix) {
+			indexes = append(indexes, i)
+			uris = append(uris, value)
+		}
+	}
+	var secrets []string
+	if len(uris) > 0 {
+		values, err := cli.resolveMany(ctx, uris, parseOpURI)
+		if err != nil {
+			return err
+		}
+		for n, i := range indexes {
+			name, _, _ := strings.Cut(env[i], "=")
+			env[i] = name + "=" + values[uris[n]]
+			secrets = append(secrets, values[uris[n]])
+		}
+	}
+	cmd.Env = env
+
+	var writers []*maskingWriter
+	if options.MaskSecrets && len(secrets) > 0 {
+		shared := cmd.Stdout != nil && sameWriter(cmd.Stdout, cmd.Stderr)
+		if cmd.Stdout != nil {
+			stdout := newMaskingWriter(cmd.Stdout, secrets)
+			writers = append(writers, stdout)
+			cmd.Stdout = stdout
+		}
+		if shared {
+			// exec.Cmd serializes writes to stdout and stderr only when both are the same writer
+			cmd.Stderr = cmd.Stdout
+		} else if cmd.Stderr != nil {
+			stderr := newMaskingWriter(cmd.Stderr, secrets)
+			writers = append(writers, stderr)
+			cmd.Stderr = stderr
+		}
+	}
+	return runCommand(ctx, cmd, writers)
+}
+
+// sameWriter reports whether a and b are the same writer, without panicking on writers which are not comparable.
+func sameWriter(a, b io.Writer) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a == b
+}
+
+// runCommand runs cmd, killing it once ctx is done, and flushes the masking writers when it exits.
+func runCommand(ctx context.Context, cmd *exec.Cmd, writers []*maskingWriter) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = cmd.Process.Kill()
+	})
+	defer stop()
+	err := cmd.Wait()
+	for _, writer := range writers {
+		if flushErr := writer.Flush(); err == nil {
+			err = flushErr
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// maskingWriter replaces secrets in everything written through it. A secret may be split between
+// two writes, so trailing bytes that could start a secret are held back until the next write or Flush.
+type maskingWriter struct {
+	w       io.Writer
+	secrets [][]byte
+	pending []byte
+}
+
+func newMaskingWriter(w io.Writer, secrets []string) *maskingWriter {
+	writer := &maskingWriter{w: w}
+	for _, secret := range secrets {
+		if secret != "" {
+			writer.secrets = append(writer.secrets, []byte(secret))
+		}
+	}
+	// longer secrets first, so a secret containing a shorter one is masked as a whole
+	sort.Slice(writer.secrets, func(i, j int) bool {
+		return len(writer.secrets[i]) > len(writer.secrets[j])
+	})
+	return writer
+}
+
+// Write implements io.Writer.
+func (m *maskingWriter) Write(p []byte) (int, error) {
+	m.pending = append(m.pending, p...)
+	if err := m.writeMasked(false); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes out the held back bytes.
+func (m *maskingWriter) Flush() error {
+	return m.writeMasked(true)
+}
+
+// writeMasked writes pending bytes with secrets masked. Unless final, it stops at the first position
+// where the pending bytes could be the beginning of a secret and keeps the rest for later.
+func (m *maskingWriter) writeMasked(final bool) error {
+	var masked bytes.Buffer
+	i := 0
+	for i < len(m.pending) {
+		rest := m.pending[i:]
+		if !final && m.isSecretPrefix(rest) {
+			break
+		}
+		if secret := m.secretAt(rest); secret != nil {
+			masked.WriteString(concealedSecret)
+			i += len(secret)
+			continue
+		}
+		masked.WriteByte(rest[0])
+		i++
+	}
+	m.pending = append(m.pending[:0], m.pending[i:]...)
+	if masked.Len() == 0 {
+		return nil
+	}
+	_, err := m.w.Write(masked.Bytes())
+	return err
+}
+
+// isSecretPrefix reports whether data is an incomplete beginning of any secret.
+func (m *maskingWriter) isSecretPrefix(data []byte) bool {
+	for _, secret := range m.secrets {
+		if len(data) < len(secret) && bytes.HasPrefix(secret, data) {
+			return true
+		}
+	}
+	return false
+}
+
+// secretAt returns the longest secret data starts with, or nil.
+func (m *maskingWriter) secretAt(data []byte) []byte {
+	for _, secret := range m.secrets {
+		if bytes.HasPrefix(data, secret) {
+			return secret
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package gonepassword
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunWithSecrets(t *testing.T) {
+	executor := itemsExecutor()
+	cli, err := New1Password(executor, OnePasswordOptions{})
+	assert.NoError(t, err)
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("sh", "-c", `echo "$DB_USER:$DB_PASSWORD:$DB_SECRET"; echo "$PLAIN $DB_PASSWORD" >&2`)
+	cmd.Env = []string{"DB_USER=op://vault/db/username", "DB_PASSWORD=op://vault/db/password",
+		"DB_SECRET=op://vault/db/pa$word", "PLAIN=plain"}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cli.RunWithSecrets(context.Background(), cmd, RunOptions{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "admin:hunter2:s3cret\n", stdout.String())
+	assert.Equal(t, "plain hunter2\n", stderr.String())
+	assert.Equal(t, map[string]int{"db": 1}, executor.Calls)
+}
+
+func TestRunWithSecretsMasksOutput(t *testing.T) {
+	cli, err := New1Password(itemsExecutor(), OnePasswordOptions{})
+	assert.NoError(t, err)
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("sh", "-c", `printf "user=%s password=" "$DB_USER"; printf "%s\n" "$DB_PASSWORD" >&2`)
+	cmd.Env = []string{"DB_USER=op://vault/db/username", "DB_PASSWORD=op://vault/db/password"}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cli.RunWithSecrets(context.Background(), cmd, RunOptions{MaskSecrets: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user=<concealed by 1Password> password=", stdout.String())
+	assert.Equal(t, "<concealed by 1Password>\n", stderr.String())
+}
+
+func TestRunWithSecretsDoesNotStartOnResolveFailure(t *testing.T) {
+	cli, err := New1Password(itemsExecutor(), OnePasswordOptions{})
+	assert.NoError(t, err)
+	cmd := exec.Command("sh", "-c", "exit 0")
+	cmd.Env = []string{"DB_PASSWORD=op://vault/db/missing"}
+
+	err = cli.RunWithSecrets(context.Background(), cmd, RunOptions{})
+
+	var resolveManyError *ResolveManyError
+	assert.True(t, errors.As(err, &resolveManyError))
+	assert.Nil(t, cmd.Process)
+}
+
+func TestRunWithSecretsKillsCommandOnCancel(t *testing.T) {
+	cli, err := New1Password(itemsExecutor(), OnePasswordOptions{})
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	cmd := exec.Command("sleep", "10")
+	cmd.Env = []string{}
+
+	start := time.Now()
+	err = cli.RunWithSecrets(ctx, cmd, RunOptions{})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestMaskingWriter(t *testing.T) {
+	var out bytes.Buffer
+	writer := newMaskingWriter(&out, []string{"secret", "secret-longer", ""})
+
+	for _, chunk := range []string{"a sec", "ret b", " secret-lon", "ger c s", "ecre"} {
+		n, err := writer.Write([]byte(chunk))
+		assert.NoError(t, err)
+		assert.Equal(t, len(chunk), n)
+	}
+	assert.NoError(t, writer.Flush())
+
+	assert.Equal(t, "a <concealed by 1Password> b <concealed by 1Password> c secre", out.String())
+}
+
+func TestRunWithSecretsMasksSharedOutput(t *testing.T) {
+	cli, err := New1Password(itemsExecutor(), OnePasswordOptions{})
+	assert.NoError(t, err)
+	var output bytes.Buffer
+	cmd := exec.Command("sh", "-c", `for i in 1 2 3 4 5; do echo "$DB_PASSWORD"; echo "$DB_PASSWORD" >&2; done`)
+	cmd.Env = []string{"DB_PASSWORD=op://vault/db/password"}
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err = cli.RunWithSecrets(context.Background(), cmd, RunOptions{MaskSecrets: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Repeat("<concealed by 1Password>\n", 10), output.String())
+	assert.Same(t, cmd.Stdout, cmd.Stderr)
+}
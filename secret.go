@@ -0,0 +1,81 @@
+package gonepassword
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+const redacted = "[REDACTED]"
+
+// Secret is a resolved secret value which prints, encodes and logs as [REDACTED].
+// Use Reveal to access the actual value.
+type Secret struct {
+	// value is kept behind a pointer, so even reflection based printing of unexported struct fields
+	// shows an address instead of the secret
+	value *string
+}
+
+// NewSecret wraps the given value in a Secret.
+func NewSecret(value string) Secret {
+	return Secret{value: &value}
+}
+
+// Reveal returns the actual secret value.
+func (s Secret) Reveal() string {
+	if s.value == nil {
+		return ""
+	}
+	return *s.value
+}
+
+// IsZero reports whether the secret holds no value.
+func (s Secret) IsZero() bool {
+	return s.Reveal() == ""
+}
+
+// String implements fmt.Stringer.
+func (s Secret) String() string {
+	return redacted
+}
+
+// GoString implements fmt.GoStringer.
+func (s Secret) GoString() string {
+	return redacted
+}
+
+// Format implements fmt.Formatter, so every verb and flag prints the redacted placeholder.
+func (s Secret) Format(f fmt.State, _ rune) {
+	_, _ = io.WriteString(f, redacted)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(redacted)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(redacted), nil
+}
+
+// LogValue implements slog.LogValuer.
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
+
+// ResolveSecret works like ResolveOpURI, but wraps the resolved value in a Secret.
+func (cli *OnePassword) ResolveSecret(uri string) (Secret, error) {
+	return cli.ResolveSecretContext(context.Background(), uri)
+}
+
+// ResolveSecretContext works like ResolveSecret, but gives up as soon as ctx is cancelled or its deadline passes.
+func (cli *OnePassword) ResolveSecretContext(ctx context.Context, uri string) (Secret, error) {
+	value, err := cli.ResolveOpURIContext(ctx, uri)
+	if err != nil {
+		return Secret{}, err
+	}
+	return NewSecret(value), nil
+}
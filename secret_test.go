@@ -0,0 +1,73 @@
+package gonepassword
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"testing"
+)
+
+type secretConfig struct {
+	Host     string
+	Password Secret
+	token    Secret
+	Nested   *secretConfig
+}
+
+func TestSecretIsRedacted(t *testing.T) {
+	config := secretConfig{
+		Host:     "localhost",
+		Password: NewSecret("hunter2"),
+		token:    NewSecret("t0ken"),
+		Nested:   &secretConfig{Password: NewSecret("hunter3")},
+	}
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x", "%10.3v"} {
+		t.Run(format, func(t *testing.T) {
+			for _, value := range []any{config, *config.Nested, config.Password, &config.Password} {
+				formatted := fmt.Sprintf(format, value)
+				assert.NotContains(t, formatted, "hunter")
+				assert.NotContains(t, formatted, "t0ken")
+			}
+		})
+	}
+	assert.Equal(t, "[REDACTED]", fmt.Sprint(config.Password))
+	assert.Equal(t, "[REDACTED]", config.Password.String())
+	assert.Equal(t, "[REDACTED]", config.Password.GoString())
+
+	encoded, err := json.Marshal(config)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(encoded), "hunter")
+	assert.Contains(t, string(encoded), `"Password":"[REDACTED]"`)
+
+	text, err := config.Password.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "[REDACTED]", string(text))
+
+	var logs bytes.Buffer
+	slog.New(slog.NewJSONHandler(&logs, nil)).Info("config", "password", config.Password, "config", config)
+	assert.NotContains(t, logs.String(), "hunter")
+	assert.Contains(t, logs.String(), `"password":"[REDACTED]"`)
+}
+
+func TestSecretReveal(t *testing.T) {
+	assert.Equal(t, "hunter2", NewSecret("hunter2").Reveal())
+	assert.False(t, NewSecret("hunter2").IsZero())
+	assert.Equal(t, "", Secret{}.Reveal())
+	assert.True(t, Secret{}.IsZero())
+}
+
+func TestResolveSecret(t *testing.T) {
+	item, err := json.Marshal(Item{ID: "item", Fields: []ItemField{{ID: "password", Value: "hunter2"}}})
+	assert.NoError(t, err)
+	cli, err := New1Password(&SpyCommandExecutor{IsCliInstalled: true, ExecuteOutput: item}, OnePasswordOptions{})
+	assert.NoError(t, err)
+
+	secret, err := cli.ResolveSecret("op://vault/item/password")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", secret.Reveal())
+	assert.Equal(t, "[REDACTED]", fmt.Sprintf("%v", secret))
+}
@@ -1,93 +1,410 @@
 package gonepassword
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
 
 // opStorage is a struct that holds the data returned by the 1Password CLI.
+// It is safe for concurrent use. Items older than ttl are treated as missing, zero ttl keeps them forever.
+// Vaults and items are keyed by their IDs, names used to fetch them are kept as aliases, so the same item
+// referenced by name and by ID is stored once.
 type opStorage struct {
+	mu     sync.RWMutex
 	Vaults map[string]opVault
+	// vaultAliases maps vault names and IDs used in uris to keys of Vaults
+	vaultAliases map[string]string
+	inFlight     map[itemRef]*itemFetch
+	ttl          time.Duration
+	now          func() time.Time
 }
 
-func newOPStorage() opStorage {
-	return opStorage{Vaults: make(map[string]opVault)}
+// itemRef identifies a single item within a vault.
+type itemRef struct {
+	vault string
+	item  string
+}
+
+// itemFetch is a single in-flight item fetch that concurrent callers can wait on.
+type itemFetch struct {
+	done chan struct{}
+	item Item
+	err  error
+	// invalidated is set when the item gets invalidated while being fetched, so the result is not cached
+	invalidated bool
+}
+
+func newOPStorage(ttl time.Duration) *opStorage {
+	return &opStorage{
+		Vaults:       make(map[string]opVault),
+		vaultAliases: make(map[string]string),
+		inFlight:     make(map[itemRef]*itemFetch),
+		ttl:          ttl,
+		now:          time.Now,
+	}
 }
 
 // setVaultItem sets the given item in the given vault.
-func (o opStorage) setVaultItem(vault string, itemRef string, item opItem) {
-	if _, ok := o.Vaults[vault]; !ok {
-		o.Vaults[vault] = opVault{ID: vault, Items: make(map[string]opItem)}
+func (o *opStorage) setVaultItem(vault string, itemRef string, item Item) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.storeVaultItem(vault, itemRef, item)
+}
+
+// storeVaultItem sets the given item in the given vault, the caller must hold the write lock.
+// The item is stored under its ID, with itemRef and its title recorded as names it can be looked up by.
+func (o *opStorage) storeVaultItem(vault string, itemRef string, item Item) {
+	key := o.vaultKey(vault)
+	if item.Vault.ID != "" && item.Vault.ID != key {
+		if key == vault {
+			// the vault was cached under its name before its ID was known, start over under the ID
+			o.deleteVault(key)
+		}
+		key = item.Vault.ID
+	}
+	o.vaultAliases[vault] = key
+	if item.Vault.Name != "" {
+		o.vaultAliases[item.Vault.Name] = key
+	}
+	if _, ok := o.Vaults[key]; !ok {
+		o.Vaults[key] = newOPVault(key)
+	}
+	v := o.Vaults[key]
+	id := item.ID
+	if id == "" {
+		id = itemRef
 	}
-	o.Vaults[vault].Items[itemRef] = item
+	v.Items[id] = item
+	v.fetchedAt[id] = o.now()
+	v.aliases[itemRef] = id
+	v.removeTitle(id)
+	switch {
+	case item.Title == itemRef:
+		// the op CLI resolved the title to this item alone, other items cached with it were renamed or deleted
+		v.titles[item.Title] = []string{id}
+	case item.Title != "":
+		v.titles[item.Title] = append(v.titles[item.Title], id)
+	}
+}
+
+// vaultKey returns the key of Vaults the given vault name or ID is stored under, the caller must hold the lock.
+func (o *opStorage) vaultKey(vault string) string {
+	if key, ok := o.vaultAliases[vault]; ok {
+		return key
+	}
+	return vault
 }
 
 // getVaultItem returns the given item from the given vault, return an error if the item or vault does not exist.
-func (o opStorage) getVaultItem(vault string, item string) (opItem, error) {
-	if _, ok := o.Vaults[vault]; !ok {
-		return opItem{}, fmt.Errorf("no such vault %s", vault)
+func (o *opStorage) getVaultItem(vault string, item string) (Item, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.lookupVaultItem(vault, item)
+}
+
+// lookupVaultItem works like getVaultItem, the caller must hold at least the read lock.
+// It returns an AmbiguousItemError when item is a title shared by more than one unexpired cached item.
+func (o *opStorage) lookupVaultItem(vault string, item string) (Item, error) {
+	v, ok := o.Vaults[o.vaultKey(vault)]
+	if !ok {
+		return Item{}, fmt.Errorf("no such vault %s", vault)
+	}
+	now := o.now()
+	expired := func(id string) bool {
+		return o.ttl > 0 && now.Sub(v.fetchedAt[id]) >= o.ttl
+	}
+	id, err := v.resolveItem(vault, item, expired)
+	if err != nil {
+		return Item{}, err
+	}
+	if expired(id) {
+		return Item{}, fmt.Errorf("item %s in vault %s has expired", item, vault)
+	}
+	return v.Items[id], nil
+}
+
+// invalidateItem removes the given item from the given vault, item may be its ID or any of its names.
+// A fetch of that item which is currently in flight will not be cached.
+func (o *opStorage) invalidateItem(vault string, item string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	key := o.vaultKey(vault)
+	if v, ok := o.Vaults[key]; ok {
+		for _, id := range v.itemIDs(item) {
+			v.removeItem(id)
+		}
+	}
+	for ref, call := range o.inFlight {
+		if ref.item == item && o.vaultKey(ref.vault) == key {
+			call.invalidated = true
+		}
+	}
+}
+
+// invalidateVault removes all items of the given vault, vault may be its ID or name.
+// Fetches of its items which are currently in flight will not be cached.
+func (o *opStorage) invalidateVault(vault string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	key := o.vaultKey(vault)
+	for ref, call := range o.inFlight {
+		if ref.vault == vault || o.vaultKey(ref.vault) == key {
+			call.invalidated = true
+		}
+	}
+	o.deleteVault(key)
+}
+
+// deleteVault removes the vault stored under the given key with all its aliases, the caller must hold the write lock.
+func (o *opStorage) deleteVault(key string) {
+	delete(o.Vaults, key)
+	for alias, aliasKey := range o.vaultAliases {
+		if aliasKey == key {
+			delete(o.vaultAliases, alias)
+		}
+	}
+}
+
+// purge removes all items from the storage.
+// Fetches which are currently in flight will not be cached.
+func (o *opStorage) purge() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.Vaults = make(map[string]opVault)
+	o.vaultAliases = make(map[string]string)
+	for _, call := range o.inFlight {
+		call.invalidated = true
+	}
+}
+
+// getOrFetchVaultItem returns the given item from the storage, calling fetch to obtain it on a miss.
+// Concurrent calls for the same item share a single fetch - only the first caller runs it while
+// the others wait for its result or for their own ctx to be done.
+func (o *opStorage) getOrFetchVaultItem(
+	ctx context.Context, vault string, item string, fetch func() (Item, error),
+) (Item, error) {
+	ref := itemRef{vault: vault, item: item}
+	for {
+		o.mu.Lock()
+		cached, err := o.lookupVaultItem(vault, item)
+		var ambiguousItemError *AmbiguousItemError
+		if err == nil || errors.As(err, &ambiguousItemError) {
+			o.mu.Unlock()
+			return cached, err
+		}
+		call, ok := o.inFlight[ref]
+		if !ok {
+			call = &itemFetch{done: make(chan struct{})}
+			o.inFlight[ref] = call
+			o.mu.Unlock()
+			return o.runFetch(ref, call, fetch)
+		}
+		o.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return Item{}, ctx.Err()
+		case <-call.done:
+		}
+		// the fetch was abandoned because its owner gave up - take over unless we have given up too
+		if isContextError(call.err) && ctx.Err() == nil {
+			continue
+		}
+		return call.item, call.err
 	}
-	if _, ok := o.Vaults[vault].Items[item]; !ok {
-		return opItem{}, fmt.Errorf("no such item %s in vault %s", item, vault)
+}
+
+// runFetch calls fetch on behalf of all callers waiting on call and stores a successful result.
+func (o *opStorage) runFetch(ref itemRef, call *itemFetch, fetch func() (Item, error)) (Item, error) {
+	call.item, call.err = fetch()
+	o.mu.Lock()
+	if call.err == nil && !call.invalidated {
+		o.storeVaultItem(ref.vault, ref.item, call.item)
 	}
-	return o.Vaults[vault].Items[item], nil
+	delete(o.inFlight, ref)
+	o.mu.Unlock()
+	close(call.done)
+	return call.item, call.err
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
 }
 
+// opVault holds the cached items of a single vault keyed by item ID.
 type opVault struct {
-	ID    string
-	Items map[string]opItem
+	ID        string
+	Items     map[string]Item
+	fetchedAt map[string]time.Time
+	// aliases maps item names and IDs used in uris to item IDs
+	aliases map[string]string
+	// titles maps item titles to IDs of all cached items with that title
+	titles map[string][]string
 }
 
-type opItem struct {
-	ID     string    `json:"id"`
-	Fields []opField `json:"fields"`
-	Files  []opFile  `json:"files"`
+func newOPVault(id string) opVault {
+	return opVault{
+		ID:        id,
+		Items:     make(map[string]Item),
+		fetchedAt: make(map[string]time.Time),
+		aliases:   make(map[string]string),
+		titles:    make(map[string][]string),
+	}
 }
 
-type opField struct {
-	ID      string    `json:"id"`
-	Type    string    `json:"type"`
-	Label   string    `json:"label"`
-	Value   string    `json:"value"`
-	Section opSection `json:"section"`
+// resolveItem returns the ID of the cached item referenced by the given ID, name or title.
+// An ID always wins, a title shared by several items is an AmbiguousItemError even if it was used
+// to fetch one of them, since 1Password would not have resolved it consistently either.
+// Expired items are left out of the title lookup, so an ambiguity expires with them.
+func (v opVault) resolveItem(vault string, item string, expired func(id string) bool) (string, error) {
+	if _, ok := v.Items[item]; ok {
+		return item, nil
+	}
+	var ids []string
+	for _, id := range v.titles[item] {
+		if !expired(id) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > 1 {
+		slices.Sort(ids)
+		return "", &AmbiguousItemError{Vault: vault, Item: item, Candidates: ids}
+	}
+	if id, ok := v.aliases[item]; ok {
+		return id, nil
+	}
+	if len(ids) == 1 {
+		return ids[0], nil
+	}
+	return "", fmt.Errorf("no such item %s in vault %s", item, vault)
 }
 
-func (of opField) matchField(uri *OpURI) bool {
-	return (of.ID == uri.field || of.Label == uri.field) && of.Section.matchSection(uri.section)
+// itemIDs returns IDs of all cached items the given ID, name or title may refer to.
+func (v opVault) itemIDs(item string) []string {
+	ids := slices.Clone(v.titles[item])
+	if id, ok := v.aliases[item]; ok {
+		ids = append(ids, id)
+	}
+	if _, ok := v.Items[item]; ok {
+		ids = append(ids, item)
+	}
+	return ids
 }
 
-type opFile struct {
-	ID      string    `json:"id"`
-	Name    string    `json:"name"`
-	Section opSection `json:"section"`
+// removeItem drops the given item with all names it can be looked up by.
+func (v opVault) removeItem(id string) {
+	delete(v.Items, id)
+	delete(v.fetchedAt, id)
+	for alias, aliasID := range v.aliases {
+		if aliasID == id {
+			delete(v.aliases, alias)
+		}
+	}
+	v.removeTitle(id)
 }
 
-func (of opFile) matchFile(uri *OpURI) bool {
-	return (of.Name == uri.field || of.ID == uri.field) && of.Section.matchSection(uri.section)
+// removeTitle drops the given item from the title index, e.g. before it is stored again under a new title.
+func (v opVault) removeTitle(id string) {
+	for title, ids := range v.titles {
+		ids = slices.DeleteFunc(ids, func(candidate string) bool { return candidate == id })
+		if len(ids) == 0 {
+			delete(v.titles, title)
+		} else {
+			v.titles[title] = ids
+		}
+	}
 }
 
-type opSection struct {
-	ID    string `json:"id"`
-	Label string `json:"label"`
+func (of ItemField) matchField(uri *OpURI) bool {
+	return (of.ID == uri.field || of.Label == uri.field) && of.Section.matchSection(uri.section)
 }
 
-func (os opSection) matchSection(section string) bool {
+// attributeValue returns the field attribute requested by the uri query, its value by default.
+// One-time passwords are generated locally at the given time, so they stay fresh while the item is cached.
+func (of ItemField) attributeValue(uri *OpURI, now time.Time) (string, error) {
+	switch uri.attribute {
+	case attributeType:
+		return of.Type, nil
+	case attributeID:
+		return of.ID, nil
+	case attributeTitle:
+		return of.Label, nil
+	case attributePurpose:
+		return of.Purpose, nil
+	case attributeOTP:
+		totp, err := of.totp(now)
+		if err != nil {
+			return "", err
+		}
+		return totp.Code, nil
+	}
+	if uri.sshFormat == sshFormatOpenSSH {
+		if of.SSHFormats.OpenSSH.Value == "" {
+			return "", fmt.Errorf("field %s has no %s format", uri.field, sshFormatOpenSSH)
+		}
+		return of.SSHFormats.OpenSSH.Value, nil
+	}
+	return of.Value, nil
+}
+
+func (of ItemFile) matchFile(uri *OpURI) bool {
+	return (of.Name == uri.field || of.ID == uri.field) && of.Section.matchSection(uri.section)
+}
+
+func (os ItemSection) matchSection(section string) bool {
 	return os.ID == section || os.Label == section ||
 		(os.ID == "add more" && section == "") // default section is `add more` in 1password :kek:
 }
 
-// GetFieldValue returns the value of the given field, returns an error if the field does not exist.
-func (o opItem) GetFieldValue(cli *OnePassword, uri *OpURI) (string, error) {
+// findField returns the field matching the given uri.
+func (o Item) findField(uri *OpURI) (ItemField, bool) {
 	for _, f := range o.Fields {
 		if f.matchField(uri) {
-			return f.Value, nil
+			return f, true
 		}
 	}
+	return ItemField{}, false
+}
+
+// GetFieldValue returns the value of the given field, returns an error if the field does not exist.
+func (o Item) GetFieldValue(ctx context.Context, cli *OnePassword, uri *OpURI) (string, error) {
+	if f, ok := o.findField(uri); ok {
+		return f.attributeValue(uri, cli.now())
+	}
+	return o.GetFileValue(ctx, cli, uri)
+}
+
+// GetFileValue returns the content of the given file, returns an error if the file does not exist.
+// Use DownloadFile to stream large or binary files instead.
+func (o Item) GetFileValue(ctx context.Context, cli *OnePassword, uri *OpURI) (string, error) {
 	for _, f := range o.Files {
 		if f.matchFile(uri) {
-			output, err := cli.executor.Execute("read", uri.raw)
+			output, err := cli.executor.Execute(ctx, "read", o.fileReference(uri, f))
 			if err != nil {
 				return "", err
 			}
 			return string(output), nil
 		}
 	}
-	return "", fmt.Errorf("field %s not found", uri.field)
+	return "", &FieldNotFoundError{Section: uri.section, Field: uri.field}
+}
+
+// fileReference returns the uri `op read` should use for the given file. IDs are preferred over names,
+// so names which the op cli would not parse, e.g. containing `/`, still work.
+func (o Item) fileReference(uri *OpURI, f ItemFile) string {
+	reference := OpURI{vault: uri.vault, item: uri.item, field: f.Name}
+	if o.Vault.ID != "" {
+		reference.vault = o.Vault.ID
+	}
+	if o.ID != "" {
+		reference.item = o.ID
+	}
+	if f.ID != "" {
+		reference.field = f.ID
+	}
+	return reference.String()
 }
@@ -0,0 +1,46 @@
+package gonepassword
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// withTempDir creates a temporary directory accessible to the current user only, calls f with its path
+// and removes the directory with everything in it afterwards. Secrets never outlive the op call they are passed to.
+func withTempDir(f func(dir string) error) (err error) {
+	dir, err := os.MkdirTemp("", "gonepassword-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(dir); err == nil {
+			err = removeErr
+		}
+	}()
+	return f(dir)
+}
+
+// withTempFile copies content into a temporary file with the given name, readable by the current user only,
+// and calls f with its path. The file is removed afterwards.
+func withTempFile(name string, content io.Reader, f func(path string) error) error {
+	name = filepath.Base(name)
+	if name == "." || name == ".." || name == string(filepath.Separator) {
+		name = "file"
+	}
+	return withTempDir(func(dir string) error {
+		path := filepath.Join(dir, name)
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(file, content); err != nil {
+			_ = file.Close()
+			return err
+		}
+		if err = file.Close(); err != nil {
+			return err
+		}
+		return f(path)
+	})
+}
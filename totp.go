@@ -0,0 +1,164 @@
+package gonepassword
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // SHA1 is the default TOTP algorithm defined by RFC 6238
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const otpFieldType = "OTP"
+const otpAuthPrefix = "otpauth://"
+
+// TOTP is a time-based one-time password code generated from an OTP field.
+type TOTP struct {
+	Code string
+	// Remaining is how long the code stays valid.
+	Remaining time.Duration
+}
+
+// totpConfig holds the parameters of an otpauth:// URI needed to generate TOTP codes.
+type totpConfig struct {
+	secret    []byte
+	digits    int
+	period    int64
+	algorithm func() hash.Hash
+}
+
+// parseTOTPConfig parses the value of an OTP field, which is either an otpauth:// URI or a bare base32 secret.
+func parseTOTPConfig(value string) (totpConfig, error) {
+	config := totpConfig{digits: 6, period: 30, algorithm: sha1.New}
+	rawSecret := value
+	if strings.HasPrefix(value, otpAuthPrefix) {
+		otpURL, err := url.Parse(value)
+		if err != nil {
+			return totpConfig{}, fmt.Errorf("invalid otpauth uri: %w", err)
+		}
+		if otpURL.Host != "totp" {
+			return totpConfig{}, fmt.Errorf("unsupported otpauth type %s - only totp is supported", otpURL.Host)
+		}
+		query := otpURL.Query()
+		rawSecret = query.Get("secret")
+		if err := config.applyQuery(query); err != nil {
+			return totpConfig{}, err
+		}
+	}
+	secret, err := decodeTOTPSecret(rawSecret)
+	if err != nil {
+		return totpConfig{}, err
+	}
+	config.secret = secret
+	return config, nil
+}
+
+// applyQuery applies the optional digits, period and algorithm otpauth:// parameters.
+func (c *totpConfig) applyQuery(query url.Values) error {
+	if digits := query.Get("digits"); digits != "" {
+		parsed, err := strconv.Atoi(digits)
+		if err != nil || parsed < 6 || parsed > 10 {
+			return fmt.Errorf("invalid otpauth digits %s", digits)
+		}
+		c.digits = parsed
+	}
+	if period := query.Get("period"); period != "" {
+		parsed, err := strconv.ParseInt(period, 10, 64)
+		if err != nil || parsed <= 0 {
+			return fmt.Errorf("invalid otpauth period %s", period)
+		}
+		c.period = parsed
+	}
+	switch algorithm := strings.ToUpper(query.Get("algorithm")); algorithm {
+	case "", "SHA1":
+		c.algorithm = sha1.New
+	case "SHA256":
+		c.algorithm = sha256.New
+	case "SHA512":
+		c.algorithm = sha512.New
+	default:
+		return fmt.Errorf("unsupported otpauth algorithm %s", algorithm)
+	}
+	return nil
+}
+
+// decodeTOTPSecret decodes a base32 secret, ignoring case, spaces and padding.
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	normalized := strings.TrimRight(strings.ToUpper(strings.ReplaceAll(secret, " ", "")), "=")
+	if normalized == "" {
+		return nil, fmt.Errorf("otp secret is empty")
+	}
+	decoded, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(normalized)
+	if err != nil {
+		return nil, fmt.Errorf("invalid otp secret: %w", err)
+	}
+	return decoded, nil
+}
+
+// generate computes the RFC 6238 code valid at the given time.
+func (c totpConfig) generate(at time.Time) TOTP {
+	unix := at.Unix()
+	counter := uint64(unix / c.period) //nolint:gosec // unix time is never negative here
+
+	mac := hmac.New(c.algorithm, c.secret)
+	_ = binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	truncated := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint64(1)
+	for i := 0; i < c.digits; i++ {
+		modulo *= 10
+	}
+	code := strconv.FormatUint(uint64(truncated)%modulo, 10)
+	remaining := time.Duration(c.period-unix%c.period) * time.Second
+	return TOTP{Code: strings.Repeat("0", c.digits-len(code)) + code, Remaining: remaining}
+}
+
+// totp generates the current code of an OTP field.
+func (of ItemField) totp(now time.Time) (TOTP, error) {
+	if of.Type != otpFieldType {
+		return TOTP{}, fmt.Errorf("field %s is not a one-time password", of.Label)
+	}
+	config, err := parseTOTPConfig(of.Value)
+	if err != nil {
+		return TOTP{}, err
+	}
+	return config.generate(now), nil
+}
+
+// ResolveTOTP resolves the given 1Password URI pointing to an OTP field and generates its current code locally.
+// The item is fetched once and cached like in ResolveOpURI, so subsequent codes need no op CLI round trip.
+func (cli *OnePassword) ResolveTOTP(uri string) (TOTP, error) {
+	return cli.ResolveTOTPContext(context.Background(), uri)
+}
+
+// ResolveTOTPContext works like ResolveTOTP, but gives up as soon as ctx is cancelled or its deadline passes.
+func (cli *OnePassword) ResolveTOTPContext(ctx context.Context, uri string) (TOTP, error) {
+	if !strings.HasPrefix(uri, opURIPrefix) {
+		return TOTP{}, &InvalidOpURIError{uri: uri}
+	}
+	opURI, err := cli.newOpURI(uri)
+	if err != nil {
+		return TOTP{}, err
+	}
+	if !cli.isInstalled {
+		return TOTP{}, &OnePasswordCliNotInstalledError{}
+	}
+	vaultItem, err := cli.fetchVaultItem(ctx, opURI.vault, opURI.item)
+	if err != nil {
+		return TOTP{}, err
+	}
+	field, ok := vaultItem.findField(opURI)
+	if !ok {
+		return TOTP{}, &FieldNotFoundError{Section: opURI.section, Field: opURI.field}
+	}
+	return field.totp(cli.now())
+}
@@ -0,0 +1,133 @@
+package gonepassword
+
+import (
+	"encoding/base32"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTOTPGenerate(t *testing.T) {
+	encode := func(secret string) string {
+		return base32.StdEncoding.EncodeToString([]byte(secret))
+	}
+	sha1Secret := encode("12345678901234567890")
+	sha256Secret := encode("12345678901234567890123456789012")
+	sha512Secret := encode("1234567890123456789012345678901234567890123456789012345678901234")
+
+	// test vectors from RFC 6238 appendix B
+	testCases := []struct {
+		name              string
+		value             string
+		unix              int64
+		expectedCode      string
+		expectedRemaining time.Duration
+	}{
+		{
+			name:              "SHA1 at 59",
+			value:             "otpauth://totp/test?digits=8&secret=" + sha1Secret,
+			unix:              59,
+			expectedCode:      "94287082",
+			expectedRemaining: time.Second,
+		},
+		{
+			name:              "SHA1 at 1111111109",
+			value:             "otpauth://totp/test?digits=8&algorithm=SHA1&secret=" + sha1Secret,
+			unix:              1111111109,
+			expectedCode:      "07081804",
+			expectedRemaining: 1 * time.Second,
+		},
+		{
+			name:              "SHA256 at 1234567890",
+			value:             "otpauth://totp/test?digits=8&algorithm=SHA256&secret=" + sha256Secret,
+			unix:              1234567890,
+			expectedCode:      "91819424",
+			expectedRemaining: 30 * time.Second,
+		},
+		{
+			name:              "SHA512 at 2000000000",
+			value:             "otpauth://totp/test?digits=8&algorithm=SHA512&secret=" + sha512Secret,
+			unix:              2000000000,
+			expectedCode:      "38618901",
+			expectedRemaining: 10 * time.Second,
+		},
+		{
+			name:              "custom period",
+			value:             "otpauth://totp/test?digits=8&period=60&secret=" + sha1Secret,
+			unix:              59,
+			expectedCode:      "84755224",
+			expectedRemaining: time.Second,
+		},
+		{
+			name:              "bare secret with default parameters",
+			value:             "gezd gnbv gy3t qojq",
+			unix:              1704067200,
+			expectedCode:      "701317",
+			expectedRemaining: 30 * time.Second,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := parseTOTPConfig(tc.value)
+			assert.NoError(t, err)
+
+			totp := config.generate(time.Unix(tc.unix, 0))
+
+			assert.Equal(t, tc.expectedCode, totp.Code)
+			assert.Equal(t, tc.expectedRemaining, totp.Remaining)
+		})
+	}
+}
+
+func TestParseTOTPConfigErrors(t *testing.T) {
+	testCases := []struct {
+		value         string
+		expectedError string
+	}{
+		{
+			value:         "otpauth://hotp/test?secret=GEZDGNBV",
+			expectedError: "unsupported otpauth type hotp - only totp is supported",
+		},
+		{value: "otpauth://totp/test?secret=GEZDGNBV&algorithm=MD5", expectedError: "unsupported otpauth algorithm MD5"},
+		{value: "otpauth://totp/test?secret=GEZDGNBV&digits=4", expectedError: "invalid otpauth digits 4"},
+		{value: "otpauth://totp/test?secret=GEZDGNBV&period=0", expectedError: "invalid otpauth period 0"},
+		{value: "otpauth://totp/test", expectedError: "otp secret is empty"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			_, err := parseTOTPConfig(tc.value)
+			assert.EqualError(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestResolveTOTP(t *testing.T) {
+	item := Item{
+		ID: "item",
+		Fields: []ItemField{
+			{ID: "password", Type: "CONCEALED", Label: "password", Value: "s3cret"},
+			{ID: "TOTP_1", Type: "OTP", Label: "one-time password", Value: "otpauth://totp/x?secret=GEZDGNBVGY3TQOJQ"},
+		},
+	}
+	itemJSON, err := json.Marshal(item)
+	assert.NoError(t, err)
+	executor := &SpyCommandExecutor{IsCliInstalled: true, ExecuteOutput: itemJSON}
+	cli, err := New1Password(executor, OnePasswordOptions{})
+	assert.NoError(t, err)
+	cli.now = func() time.Time { return time.Unix(1704067210, 0) }
+
+	totp, err := cli.ResolveTOTP("op://vault/item/one-time password")
+	assert.NoError(t, err)
+	assert.Equal(t, TOTP{Code: "701317", Remaining: 20 * time.Second}, totp)
+
+	_, err = cli.ResolveTOTP("op://vault/item/password")
+	assert.EqualError(t, err, "field password is not a one-time password")
+
+	_, err = cli.ResolveTOTP("op://vault/item/missing")
+	assert.EqualError(t, err, "field missing not found")
+	var fieldNotFoundError *FieldNotFoundError
+	assert.ErrorAs(t, err, &fieldNotFoundError)
+}
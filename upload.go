@@ -0,0 +1,58 @@
+package gonepassword
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// documentCreated is the output of `op document create --format json`.
+type documentCreated struct {
+	UUID string `json:"uuid"`
+}
+
+// assignmentEscaper escapes characters with a meaning in op item assignment statements.
+var assignmentEscaper = strings.NewReplacer(`\`, `\\`, ".", `\.`, "=", `\=`)
+
+// AttachFile uploads the content of r as a file called name to the given item, replacing a file of that name
+// in the same section. Section is optional. The cached copy of the item is replaced with the edited item.
+// The content is passed to the op CLI through a temporary file readable by the current user only.
+func (cli *OnePassword) AttachFile(
+	ctx context.Context, vault string, item string, section string, name string, r io.Reader,
+) (Item, error) {
+	if name == "" {
+		return Item{}, errors.New("file name must not be empty")
+	}
+	field := assignmentEscaper.Replace(name)
+	if section != "" {
+		field = assignmentEscaper.Replace(section) + "." + field
+	}
+	var edited Item
+	err := withTempFile(name, r, func(path string) error {
+		return cli.executeJSON(ctx, &edited, "item", "edit", item, "--vault", vault, field+"[file]="+path)
+	})
+	if err != nil {
+		return Item{}, err
+	}
+	cli.invalidateItem(vault, item)
+	cli.setVaultItem(vault, item, edited)
+	return edited.clone(), nil
+}
+
+// CreateDocument creates a DOCUMENT item with the given title holding the content of r and returns its ID.
+// The title is used as the file name of the document too.
+func (cli *OnePassword) CreateDocument(ctx context.Context, vault string, title string, r io.Reader) (string, error) {
+	if title == "" {
+		return "", errors.New("document title must not be empty")
+	}
+	var created documentCreated
+	err := withTempFile(title, r, func(path string) error {
+		return cli.executeJSON(ctx, &created, "document", "create", path, "--vault", vault,
+			"--title", title, "--file-name", title)
+	})
+	if err != nil {
+		return "", err
+	}
+	return created.UUID, nil
+}
@@ -0,0 +1,65 @@
+package gonepassword
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAttachFile(t *testing.T) {
+	edited, err := json.Marshal(Item{ID: "abc123", Title: "tls", Version: 4,
+		Files: []ItemFile{{ID: "f1", Name: "bundle.pem", Section: ItemSection{ID: "certs", Label: "certs"}}}})
+	assert.NoError(t, err)
+	executor := &TempFileCommandExecutor{Output: edited}
+	cli, err := New1Password(executor, OnePasswordOptions{})
+	assert.NoError(t, err)
+	cli.setVaultItem("vault", "tls", Item{ID: "abc123", Title: "tls", Version: 3})
+
+	item, err := cli.AttachFile(context.Background(), "vault", "tls", "certs", "bundle.pem",
+		strings.NewReader("-----BEGIN CERTIFICATE-----"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, item.Version)
+	assert.Equal(t, [][]string{{"item", "edit", "tls", "--vault", "vault",
+		`certs.bundle\.pem[file]=` + executor.Path, "--format", "json"}}, executor.Calls)
+	assert.Equal(t, "bundle.pem", filepath.Base(executor.Path))
+	assert.Equal(t, "-----BEGIN CERTIFICATE-----", string(executor.Content))
+	assert.Equal(t, os.FileMode(0o600), executor.FileMode)
+	_, err = os.Stat(filepath.Dir(executor.Path))
+	assert.True(t, os.IsNotExist(err), "temporary directory should be removed")
+
+	cached, err := cli.getVaultItem("vault", "tls")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, cached.Version)
+}
+
+func TestCreateDocument(t *testing.T) {
+	executor := &TempFileCommandExecutor{Output: []byte(`{"uuid": "doc123", "vaultUuid": "v1"}`)}
+	cli, err := New1Password(executor, OnePasswordOptions{})
+	assert.NoError(t, err)
+
+	id, err := cli.CreateDocument(context.Background(), "vault", "keystore.p12", strings.NewReader("\x00\xffkey"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "doc123", id)
+	assert.Equal(t, [][]string{{"document", "create", executor.Path, "--vault", "vault",
+		"--title", "keystore.p12", "--file-name", "keystore.p12", "--format", "json"}}, executor.Calls)
+	assert.Equal(t, []byte("\x00\xffkey"), executor.Content)
+	assert.Equal(t, os.FileMode(0o600), executor.FileMode)
+	_, err = os.Stat(executor.Path)
+	assert.True(t, os.IsNotExist(err), "temporary file should be removed")
+}
+
+func TestUploadRequiresName(t *testing.T) {
+	cli, err := New1Password(&TempFileCommandExecutor{}, OnePasswordOptions{})
+	assert.NoError(t, err)
+
+	_, err = cli.AttachFile(context.Background(), "vault", "tls", "", "", strings.NewReader(""))
+	assert.EqualError(t, err, "file name must not be empty")
+	_, err = cli.CreateDocument(context.Background(), "vault", "", strings.NewReader(""))
+	assert.EqualError(t, err, "document title must not be empty")
+}